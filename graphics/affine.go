@@ -6,10 +6,11 @@ package graphics
 
 import (
 	"github.com/image-server/graphics-go/graphics/interp"
-	"errors"
 	"image"
 	"image/draw"
 	"math"
+	"strconv"
+	"strings"
 )
 
 // I is the identity Affine transform matrix.
@@ -38,32 +39,143 @@ func (a Affine) Mul(b Affine) Affine {
 	}
 }
 
-func (a Affine) transformRGBA(dst *image.RGBA, src *image.RGBA, i interp.RGBA) error {
+// MulInto stores the multiplication of a and b in dst, avoiding the copies
+// made by Mul. dst may alias a or b.
+func MulInto(dst, a, b *Affine) {
+	m0 := a[0]*b[0] + a[1]*b[3] + a[2]*b[6]
+	m1 := a[0]*b[1] + a[1]*b[4] + a[2]*b[7]
+	m2 := a[0]*b[2] + a[1]*b[5] + a[2]*b[8]
+	m3 := a[3]*b[0] + a[4]*b[3] + a[5]*b[6]
+	m4 := a[3]*b[1] + a[4]*b[4] + a[5]*b[7]
+	m5 := a[3]*b[2] + a[4]*b[5] + a[5]*b[8]
+	m6 := a[6]*b[0] + a[7]*b[3] + a[8]*b[6]
+	m7 := a[6]*b[1] + a[7]*b[4] + a[8]*b[7]
+	m8 := a[6]*b[2] + a[7]*b[5] + a[8]*b[8]
+	dst[0], dst[1], dst[2] = m0, m1, m2
+	dst[3], dst[4], dst[5] = m3, m4, m5
+	dst[6], dst[7], dst[8] = m6, m7, m8
+}
+
+// Equal reports whether each element of a is within epsilon of the
+// corresponding element of b.
+func (a Affine) Equal(b Affine, epsilon float64) bool {
+	for i := range a {
+		if !(math.Abs(a[i]-b[i]) <= epsilon) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a Affine) transformRGBA(dst *image.RGBA, src *image.RGBA, i interp.RGBA, cancel <-chan struct{}) error {
 	srcb := src.Bounds()
 	b := dst.Bounds()
-	for y := b.Min.Y; y < b.Max.Y; y++ {
-		for x := b.Min.X; x < b.Max.X; x++ {
-			sx, sy := a.pt(x, y)
-			if inBounds(srcb, sx, sy) {
-				c := i.RGBA(src, sx, sy)
-				off := (y-dst.Rect.Min.Y)*dst.Stride + (x-dst.Rect.Min.X)*4
-				dst.Pix[off+0] = c.R
-				dst.Pix[off+1] = c.G
-				dst.Pix[off+2] = c.B
-				dst.Pix[off+3] = c.A
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			if canceled(cancel) {
+				return
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				sx, sy := a.pt(x, y)
+				if inBounds(srcb, sx, sy) {
+					c := i.RGBA(src, sx, sy)
+					off := (y-dst.Rect.Min.Y)*dst.Stride + (x-dst.Rect.Min.X)*4
+					dst.Pix[off+0] = c.R
+					dst.Pix[off+1] = c.G
+					dst.Pix[off+2] = c.B
+					dst.Pix[off+3] = c.A
+				}
+			}
+		}
+	})
+	if canceled(cancel) {
+		return ErrCanceled
+	}
+	return nil
+}
+
+func (a Affine) transformGray(dst *image.Gray, src *image.Gray, i interp.Gray, cancel <-chan struct{}) error {
+	srcb := src.Bounds()
+	b := dst.Bounds()
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			if canceled(cancel) {
+				return
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				sx, sy := a.pt(x, y)
+				if inBounds(srcb, sx, sy) {
+					dst.Pix[dst.PixOffset(x, y)] = i.Gray(src, sx, sy).Y
+				}
 			}
 		}
+	})
+	if canceled(cancel) {
+		return ErrCanceled
+	}
+	return nil
+}
+
+// singularEpsilon is the smallest magnitude of the determinant of a valid
+// transform, relative to the magnitudes of the products it is the
+// difference of. Being relative, it rejects matrices that are singular but
+// for rounding error while accepting extreme uniform scales.
+const singularEpsilon = 1e-12
+
+// Valid returns ErrSingularTransform if a is degenerate: if it has
+// non-finite elements, as produced by scaling by zero, or its determinant
+// is zero.
+func (a Affine) Valid() error {
+	for _, v := range a {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return ErrSingularTransform
+		}
+	}
+	if math.Abs(a.det()) <= singularEpsilon*(math.Abs(a[0]*a[4])+math.Abs(a[1]*a[3])) {
+		return ErrSingularTransform
 	}
 	return nil
 }
 
 // Transform applies the affine transform to src and produces dst.
+// It returns an error if a is not Valid. dst and src may share pixels, as
+// for an in-place transform.
 func (a Affine) Transform(dst draw.Image, src image.Image, i interp.Interp) error {
+	return a.transform(dst, src, i, nil)
+}
+
+// TransformCancel is like Transform, but stops early and returns
+// ErrCanceled once cancel is closed.
+func (a Affine) TransformCancel(dst draw.Image, src image.Image, i interp.Interp, cancel <-chan struct{}) error {
+	return a.transform(dst, src, i, cancel)
+}
+
+func (a Affine) transform(dst draw.Image, src image.Image, i interp.Interp, cancel <-chan struct{}) error {
 	if dst == nil {
-		return errors.New("graphics: dst is nil")
+		return ErrNilDst
 	}
 	if src == nil {
-		return errors.New("graphics: src is nil")
+		return ErrNilSrc
+	}
+	if err := a.Valid(); err != nil {
+		return err
+	}
+	if aliases(dst, src) {
+		// Work from a copy, as writing dst would overwrite pixels of src
+		// still to be read.
+		src = clone(src)
+	}
+
+	// Translations by whole pixels, including the identity, are a lossless
+	// block copy.
+	if d, ok := a.intTranslation(); ok {
+		r := dst.Bounds().Intersect(src.Bounds().Sub(d))
+		draw.Draw(dst, r, src, r.Min.Add(d), draw.Src)
+		return nil
+	}
+	// So are quarter turns and flips that keep pixels whole.
+	if m, ok := a.intPermutation(); ok {
+		return permute(dst, src, m, cancel)
 	}
 
 	// RGBA fast path.
@@ -71,22 +183,155 @@ func (a Affine) Transform(dst draw.Image, src image.Image, i interp.Interp) erro
 	srcRGBA, srcOk := src.(*image.RGBA)
 	interpRGBA, interpOk := i.(interp.RGBA)
 	if dstOk && srcOk && interpOk {
-		return a.transformRGBA(dstRGBA, srcRGBA, interpRGBA)
+		return a.transformRGBA(dstRGBA, srcRGBA, interpRGBA, cancel)
 	}
 
+	// Gray fast path.
+	dstGray, dstOk := dst.(*image.Gray)
+	srcGray, srcOk := src.(*image.Gray)
+	interpGray, interpOk := i.(interp.Gray)
+	if dstOk && srcOk && interpOk {
+		return a.transformGray(dstGray, srcGray, interpGray, cancel)
+	}
+
+	// The 8-bit color models truncate the interpolated 16-bit channels,
+	// which darkens dark images and brightens light ones a little more
+	// with each transform, so round them first.
+	round := !is16Bit(dst)
 	srcb := src.Bounds()
 	b := dst.Bounds()
 	for y := b.Min.Y; y < b.Max.Y; y++ {
+		if canceled(cancel) {
+			return ErrCanceled
+		}
 		for x := b.Min.X; x < b.Max.X; x++ {
 			sx, sy := a.pt(x, y)
 			if inBounds(srcb, sx, sy) {
-				dst.Set(x, y, i.Interp(src, sx, sy))
+				c := i.Interp(src, sx, sy)
+				if round {
+					c = round8(c)
+				}
+				dst.Set(x, y, c)
+			}
+		}
+	}
+	return nil
+}
+
+// identityEpsilon is the largest difference from a whole number, per
+// element, for which a matrix is treated as an integer translation. It
+// absorbs the rounding error left by composing transforms that cancel out.
+const identityEpsilon = 1e-9
+
+// intTranslation reports whether a is a translation by a whole number of
+// pixels, and if so returns the offset from dst to src.
+func (a Affine) intTranslation() (d image.Point, ok bool) {
+	near := func(x, y float64) bool { return math.Abs(x-y) <= identityEpsilon }
+	if !near(a[0], 1) || !near(a[1], 0) || !near(a[3], 0) || !near(a[4], 1) {
+		return image.Point{}, false
+	}
+	if !near(a[6], 0) || !near(a[7], 0) || !near(a[8], 1) {
+		return image.Point{}, false
+	}
+	tx, ty := math.Floor(a[2]+0.5), math.Floor(a[5]+0.5)
+	if !near(a[2], tx) || !near(a[5], ty) {
+		return image.Point{}, false
+	}
+	return image.Pt(int(tx), int(ty)), true
+}
+
+// intPermutation reports whether a maps each pixel center of dst onto a
+// pixel center of src, as quarter turns and flips combined with whole
+// pixel translations do, and if so returns the integer matrix m that maps
+// pixel (x, y) of dst to pixel (m[0]*x+m[1]*y+m[2], m[3]*x+m[4]*y+m[5]) of
+// src.
+func (a Affine) intPermutation() (m [6]int, ok bool) {
+	near := func(x, y float64) bool { return math.Abs(x-y) <= identityEpsilon }
+	if !near(a[6], 0) || !near(a[7], 0) || !near(a[8], 1) {
+		return m, false
+	}
+	for row := 0; row < 2; row++ {
+		// The linear part must be a signed permutation.
+		nonzero := 0
+		for col := 0; col < 2; col++ {
+			v := a[row*3+col]
+			switch {
+			case near(v, 1):
+				m[row*3+col] = 1
+			case near(v, -1):
+				m[row*3+col] = -1
+			case !near(v, 0):
+				return m, false
+			}
+			if m[row*3+col] != 0 {
+				nonzero++
+			}
+		}
+		if nonzero != 1 {
+			return m, false
+		}
+		// Pixel centers are offset by a half, so the translation of pixel
+		// indices must be whole.
+		t := a[row*3+2] + float64(m[row*3]+m[row*3+1]-1)/2
+		ti := math.Floor(t + 0.5)
+		if !near(t, ti) {
+			return m, false
+		}
+		m[row*3+2] = int(ti)
+	}
+	// Each row must take a different axis.
+	if (m[0] != 0) != (m[4] != 0) {
+		return m, false
+	}
+	return m, true
+}
+
+// permute copies each pixel of src to the pixel of dst that m, as returned
+// by intPermutation, maps onto it. Pixels of dst that map outside src are
+// left unchanged.
+func permute(dst draw.Image, src image.Image, m [6]int, cancel <-chan struct{}) error {
+	b, sb := dst.Bounds(), src.Bounds()
+	d, dok := dst.(*image.RGBA)
+	s, sok := src.(*image.RGBA)
+	rows := parallelRows
+	if !dok || !sok {
+		// Other images need not allow concurrent writes.
+		rows = func(y0, y1 int, fn func(y0, y1 int)) { fn(y0, y1) }
+	}
+	rows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			if canceled(cancel) {
+				return
+			}
+			for x := b.Min.X; x < b.Max.X; x++ {
+				sp := image.Pt(m[0]*x+m[1]*y+m[2], m[3]*x+m[4]*y+m[5])
+				if !sp.In(sb) {
+					continue
+				}
+				if dok && sok {
+					do, so := d.PixOffset(x, y), s.PixOffset(sp.X, sp.Y)
+					copy(d.Pix[do:do+4], s.Pix[so:so+4])
+				} else {
+					dst.Set(x, y, src.At(sp.X, sp.Y))
+				}
 			}
 		}
+	})
+	if canceled(cancel) {
+		return ErrCanceled
 	}
 	return nil
 }
 
+// WillResample reports whether Transform with a interpolates between
+// source pixels. It returns false for the identity, for translations by
+// whole pixels, and for quarter turns and flips that map pixels onto
+// pixels, all of which Transform copies losslessly.
+func (a Affine) WillResample() bool {
+	_, ok := a.intPermutation()
+	return !ok
+}
+
 func inBounds(b image.Rectangle, x, y float64) bool {
 	if x < float64(b.Min.X) || x >= float64(b.Max.X) {
 		return false
@@ -110,10 +355,10 @@ func (a Affine) pt(x0, y0 int) (x1, y1 float64) {
 //   a.CenterFit(dst, src).Transform(dst, src, i).
 func (a Affine) TransformCenter(dst draw.Image, src image.Image, i interp.Interp) error {
 	if dst == nil {
-		return errors.New("graphics: dst is nil")
+		return ErrNilDst
 	}
 	if src == nil {
-		return errors.New("graphics: src is nil")
+		return ErrNilSrc
 	}
 
 	return a.CenterFit(dst.Bounds(), src.Bounds()).Transform(dst, src, i)
@@ -172,3 +417,85 @@ func (a Affine) CenterFit(dst, src image.Rectangle) Affine {
 	sy := float64(src.Min.Y) + float64(src.Dy())/2
 	return I.Translate(-sx, -sy).Mul(a).Translate(dx, dy)
 }
+
+// ScaleFactors returns the factors by which a scales the x and y axes of
+// src onto dst, the lengths of the images of the unit vectors. A rotation
+// or translation has factors of 1. Both factors are infinite for a
+// degenerate transform.
+func (a Affine) ScaleFactors() (sx, sy float64) {
+	// a maps dst to src, so the factors come from the columns of the
+	// inverse of its linear part.
+	d := math.Abs(a.det())
+	if d == 0 {
+		return math.Inf(1), math.Inf(1)
+	}
+	return math.Hypot(a[4], a[3]) / d, math.Hypot(a[1], a[0]) / d
+}
+
+// AffineFromPoints returns the transform that maps each of the points src
+// onto the corresponding point of dst, when used with Transform. As for
+// the other transforms, the points are continuous co-ordinates, so pixel
+// (x, y) spans from (x, y) to (x+1, y+1). It returns ErrSingularTransform
+// if either set of points is collinear, as the transform is then
+// degenerate or not unique.
+func AffineFromPoints(src, dst [3]image.Point) (Affine, error) {
+	// a maps dst to src, so solve for the linear part L with
+	//	L (dst[1]-dst[0]) = src[1]-src[0]
+	//	L (dst[2]-dst[0]) = src[2]-src[0]
+	// and then the translation that takes dst[0] to src[0].
+	u, v := dst[1].Sub(dst[0]), dst[2].Sub(dst[0])
+	du, dv := src[1].Sub(src[0]), src[2].Sub(src[0])
+	d := float64(u.X*v.Y - v.X*u.Y)
+	if d == 0 {
+		return Affine{}, ErrSingularTransform
+	}
+	a := Affine{
+		float64(du.X*v.Y-dv.X*u.Y) / d, float64(dv.X*u.X-du.X*v.X) / d, 0,
+		float64(du.Y*v.Y-dv.Y*u.Y) / d, float64(dv.Y*u.X-du.Y*v.X) / d, 0,
+		0, 0, 1,
+	}
+	a[2] = float64(src[0].X) - a[0]*float64(dst[0].X) - a[1]*float64(dst[0].Y)
+	a[5] = float64(src[0].Y) - a[3]*float64(dst[0].X) - a[4]*float64(dst[0].Y)
+	if err := a.Valid(); err != nil {
+		return Affine{}, err
+	}
+	return a, nil
+}
+
+// CSSMatrix returns a in the form of the CSS transform function
+// matrix(a, b, c, d, e, f). CSS transforms map the element to the page, the
+// reverse of an Affine, which maps dst to src, so the result is the
+// inverse of a. It applies about the element's top-left corner, so the
+// element needs "transform-origin: 0 0". CSSMatrix returns the empty
+// string if a is degenerate.
+func (a Affine) CSSMatrix() string {
+	return a.webMatrix(", ")
+}
+
+// SVGTransform returns a in the form of the SVG transform attribute value
+// matrix(a b c d e f). Like CSSMatrix, it is the inverse of a, and it
+// returns the empty string if a is degenerate.
+func (a Affine) SVGTransform() string {
+	return a.webMatrix(" ")
+}
+
+// webMatrix returns the inverse of a in the form matrix(a, b, c, d, e, f)
+// of CSS and SVG, which is column major, with the elements separated by
+// sep.
+func (a Affine) webMatrix(sep string) string {
+	if a.Valid() != nil {
+		return ""
+	}
+	fwd, _ := a.invert()
+	var s []string
+	for _, v := range []float64{fwd[0], fwd[3], fwd[1], fwd[4], fwd[2], fwd[5]} {
+		// Round away the error of trigonometry and inversion, such as
+		// the cosine of a quarter turn, and negative zero.
+		v = math.Floor(v*1e10+0.5) / 1e10
+		if v == 0 {
+			v = 0
+		}
+		s = append(s, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	return "matrix(" + strings.Join(s, sep) + ")"
+}
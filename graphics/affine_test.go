@@ -0,0 +1,356 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestMulInto(t *testing.T) {
+	a := I.Rotate(0.7).Translate(3, -2)
+	b := I.Scale(1.5, 0.25).Shear(0.1, 0.3)
+	want := a.Mul(b)
+
+	var got Affine
+	MulInto(&got, &a, &b)
+	if got != want {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// dst may alias either operand.
+	c := a
+	MulInto(&c, &c, &b)
+	if c != want {
+		t.Errorf("aliased a: got %v want %v", c, want)
+	}
+	c = b
+	MulInto(&c, &a, &c)
+	if c != want {
+		t.Errorf("aliased b: got %v want %v", c, want)
+	}
+}
+
+func TestAffineEqual(t *testing.T) {
+	a := I.Rotate(0.7).Translate(3, -2)
+	b := a
+	b[2] += 1e-12
+	if !a.Equal(b, 1e-9) {
+		t.Errorf("%v and %v not equal at epsilon 1e-9", a, b)
+	}
+	if a.Equal(b, 0) {
+		t.Errorf("%v and %v equal at epsilon 0", a, b)
+	}
+	if !a.Equal(a, 0) {
+		t.Errorf("%v not equal to itself", a)
+	}
+
+	// A transform composed with its inverse is the identity, up to
+	// rounding.
+	inv := I.Translate(-3, 2).Rotate(-0.7)
+	if got := a.Mul(inv); !got.Equal(I, 1e-9) {
+		t.Errorf("got %v want the identity", got)
+	}
+}
+
+var benchAffine Affine
+
+func BenchmarkMul(b *testing.B) {
+	x, y := I.Rotate(0.7), I.Scale(1.5, 0.25)
+	m := I
+	for i := 0; i < b.N; i++ {
+		m = m.Mul(x).Mul(y)
+	}
+	benchAffine = m
+}
+
+func BenchmarkMulInto(b *testing.B) {
+	x, y := I.Rotate(0.7), I.Scale(1.5, 0.25)
+	m := I
+	for i := 0; i < b.N; i++ {
+		MulInto(&m, &m, &x)
+		MulInto(&m, &m, &y)
+	}
+	benchAffine = m
+}
+
+func TestTransformIdentity(t *testing.T) {
+	src := NewNoise(20, 15, 2)
+	for _, a := range []Affine{
+		I,
+		// Cancelling operations leave rounding error behind.
+		I.Rotate(0.3).Scale(3, 7).Scale(1.0/3, 1.0/7).Rotate(-0.3),
+	} {
+		dst := image.NewRGBA(src.Bounds())
+		if err := a.Transform(dst, src, interp.Bilinear); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dst.Pix, src.Pix) {
+			t.Errorf("%v: output is not a copy of src", a)
+		}
+	}
+}
+
+func TestTransformIntTranslation(t *testing.T) {
+	src := NewNoise(30, 20, 3)
+	dst := image.NewRGBA(src.Bounds())
+	if err := I.Translate(10, -5).Transform(dst, src, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sx, sy := x-10, y+5
+			want := color.RGBA{}
+			if image.Pt(sx, sy).In(b) {
+				want = src.RGBAAt(sx, sy)
+			}
+			if got := dst.RGBAAt(x, y); got != want {
+				t.Fatalf("(%d, %d): got %v want %v", x, y, got, want)
+			}
+		}
+	}
+
+	// A half pixel translation must still interpolate.
+	if _, ok := I.Translate(0.5, 0).intTranslation(); ok {
+		t.Error("half pixel translation treated as integral")
+	}
+}
+
+func TestTransformDegenerate(t *testing.T) {
+	src := NewNoise(4, 4, 1)
+	dst := image.NewRGBA(src.Bounds())
+	tests := []Affine{
+		I.Scale(0, 1),
+		I.Scale(1, 0),
+		{1, 2, 0, 2, 4, 0, 0, 0, 1},
+		// Singular but for rounding error.
+		I.Rotate(0.3).Scale(0, 1).Rotate(0.7),
+	}
+	for _, a := range tests {
+		if err := a.Valid(); err != ErrSingularTransform {
+			t.Errorf("%v: Valid got %v want %v", a, err, ErrSingularTransform)
+		}
+		if err := a.Transform(dst, src, interp.Bilinear); err != ErrSingularTransform {
+			t.Errorf("%v: Transform got %v want %v", a, err, ErrSingularTransform)
+		}
+	}
+	for _, a := range []Affine{
+		I.Rotate(1).Scale(0.01, 100),
+		// Extreme scales are valid however small the determinant.
+		I.Scale(1e-7, 1e-7),
+		I.Rotate(1).Scale(1e-9, 1e-9),
+		I.Scale(1e7, 1e7),
+	} {
+		if err := a.Valid(); err != nil {
+			t.Errorf("%v: valid transform got %v", a, err)
+		}
+	}
+}
+
+func TestScaleFactors(t *testing.T) {
+	tests := []struct {
+		desc   string
+		a      Affine
+		sx, sy float64
+	}{
+		{"identity", I, 1, 1},
+		{"scale", I.Scale(2, 3), 2, 3},
+		{"rotate", I.Rotate(0.7), 1, 1},
+		{"scale rotate", I.Scale(2, 3).Rotate(math.Pi / 2), 2, 3},
+		{"translate", I.Scale(0.5, 4).Translate(10, -20), 0.5, 4},
+	}
+	for _, tt := range tests {
+		sx, sy := tt.a.ScaleFactors()
+		if math.Abs(sx-tt.sx) > 1e-9 || math.Abs(sy-tt.sy) > 1e-9 {
+			t.Errorf("%s: got (%v, %v) want (%v, %v)", tt.desc, sx, sy, tt.sx, tt.sy)
+		}
+	}
+}
+
+func TestWillResample(t *testing.T) {
+	tests := []struct {
+		desc string
+		a    Affine
+		want bool
+	}{
+		{"identity", I, false},
+		{"integer translate", I.Translate(3, -7), false},
+		{"round trip", I.Rotate(0.3).Translate(2, 1).Translate(-2, -1).Rotate(-0.3), false},
+		{"half-pixel translate", I.Translate(0.5, 0), true},
+		{"rotate", I.Rotate(0.1), true},
+		{"scale", I.Scale(2, 2), true},
+		{"quarter turn", I.Rotate(math.Pi/2).Translate(5, 0), false},
+		{"half turn", I.Rotate(math.Pi), false},
+		{"flip", I.Scale(-1, 1).Translate(4, 0), false},
+		{"quarter turn, half-pixel translate", I.Rotate(math.Pi/2).Translate(0.5, 0), true},
+		{"quarter turn, doubled", I.Rotate(math.Pi/2).Scale(2, 1), true},
+	}
+	for _, tt := range tests {
+		if got := tt.a.WillResample(); got != tt.want {
+			t.Errorf("%s: got %v want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestAffineFromPoints(t *testing.T) {
+	// A quarter turn, doubling and translation map whole pixels to whole
+	// pixels, so the points are exact.
+	want := I.Translate(-10, 5).Scale(2, 2).Rotate(math.Pi/2).Translate(40, -3)
+	fwd, _ := want.invert()
+	src := [3]image.Point{{0, 0}, {10, 0}, {3, 7}}
+	var dst [3]image.Point
+	for i, p := range src {
+		x := float64(p.X)*fwd[0] + float64(p.Y)*fwd[1] + fwd[2]
+		y := float64(p.X)*fwd[3] + float64(p.Y)*fwd[4] + fwd[5]
+		dst[i] = image.Pt(int(math.Floor(x+0.5)), int(math.Floor(y+0.5)))
+	}
+	got, err := AffineFromPoints(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want, 1e-9) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// Collinear points on either side have no valid transform.
+	line := [3]image.Point{{0, 0}, {1, 1}, {5, 5}}
+	if _, err := AffineFromPoints(line, dst); err == nil {
+		t.Error("collinear src: expected error")
+	}
+	if _, err := AffineFromPoints(src, line); err == nil {
+		t.Error("collinear dst: expected error")
+	}
+}
+
+func TestWebMatrix(t *testing.T) {
+	tests := []struct {
+		a        Affine
+		css, svg string
+	}{
+		{I, "matrix(1, 0, 0, 1, 0, 0)", "matrix(1 0 0 1 0 0)"},
+		// Scale by 2 and 3, then move 10 right and 20 down:
+		//	x' = 2x + 10, y' = 3y + 20.
+		{I.Scale(2, 3).Translate(10, 20), "matrix(2, 0, 0, 3, 10, 20)", "matrix(2 0 0 3 10 20)"},
+		// A clockwise quarter turn takes (1, 0) to (0, 1) and (0, 1)
+		// to (-1, 0), in the y-down space of CSS and SVG.
+		{I.Rotate(math.Pi / 2), "matrix(0, 1, -1, 0, 0, 0)", "matrix(0 1 -1 0 0 0)"},
+		{I.Rotate(math.Pi/2).Translate(5, 0).Scale(0.5, 0.5), "matrix(0, 0.5, -0.5, 0, 2.5, 0)", "matrix(0 0.5 -0.5 0 2.5 0)"},
+		{I.Scale(0, 1), "", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.a.CSSMatrix(); got != tt.css {
+			t.Errorf("%v: CSSMatrix got %q want %q", tt.a, got, tt.css)
+		}
+		if got := tt.a.SVGTransform(); got != tt.svg {
+			t.Errorf("%v: SVGTransform got %q want %q", tt.a, got, tt.svg)
+		}
+	}
+}
+
+func TestTransformInPlace(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := crop(src, src.Bounds())
+	a := I.Rotate(0.4).CenterFit(m.Bounds(), m.Bounds())
+
+	// Transform leaves the pixels that map outside src alone, so the
+	// expected result starts as a copy of src too.
+	want := crop(m, m.Bounds())
+	if err := a.Transform(want, m, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	got := crop(m, m.Bounds())
+	if err := a.Transform(got, got, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+		t.Error(err)
+	}
+
+	// So does a sub-image of src, here shifted by a translation that
+	// reads pixels already written.
+	shift := I.Translate(10.5, 10.5)
+	want = crop(m, m.Bounds())
+	r := image.Rect(20, 20, 400, 600)
+	if err := shift.Transform(want.SubImage(r).(*image.RGBA), crop(m, m.Bounds()), interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	got = crop(m, m.Bounds())
+	if err := shift.Transform(got.SubImage(r).(*image.RGBA), got, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+		t.Errorf("sub-image: %v", err)
+	}
+}
+
+func TestTransformRounding(t *testing.T) {
+	n := NewNoise(40, 40, 1)
+	for _, base := range []uint8{0x20, 0x80, 0xe0} {
+		// A gray image with a little noise, so that interpolation rarely
+		// lands on a whole 8-bit value.
+		m := image.NewNRGBA(n.Bounds())
+		var before int
+		for i := 0; i < len(m.Pix); i += 4 {
+			v := base - 0x10 + n.Pix[i]%0x20
+			m.Pix[i], m.Pix[i+1], m.Pix[i+2], m.Pix[i+3] = v, v, v, 0xff
+			before += int(v)
+		}
+
+		// Shift it back and forth by a quarter pixel.
+		for k := 0; k < 20; k++ {
+			s := 0.25
+			if k%2 == 1 {
+				s = -s
+			}
+			d := image.NewNRGBA(m.Bounds())
+			if err := I.Translate(s, s).Transform(d, m, interp.Bilinear); err != nil {
+				t.Fatal(err)
+			}
+			m = d
+		}
+		var after int
+		for i := 0; i < len(m.Pix); i += 4 {
+			after += int(m.Pix[i])
+		}
+		px := float64(len(m.Pix) / 4)
+		if d := math.Abs(float64(after-before) / px); d > 1 {
+			t.Errorf("base %#x: mean drifted by %.2f after 20 transforms, want at most 1", base, d)
+		}
+	}
+}
+
+func TestTransformQuarterTurns(t *testing.T) {
+	src := NewNoise(7, 5, 1)
+	for k := 1; k < 4; k++ {
+		want := rotateQuarters(src, k)
+		wb := want.Bounds()
+		// Rotating about the center of src, then moving the result to the
+		// origin, is exact whenever the parities allow.
+		a := I.Rotate(float64(k)*math.Pi/2).Center(3.5, 2.5).
+			Translate(float64(wb.Dx()-7)/2, float64(wb.Dy()-5)/2)
+		if a.WillResample() {
+			t.Fatalf("k=%d: %v resamples", k, a)
+		}
+		for _, dst := range []draw.Image{image.NewRGBA(wb), image.NewNRGBA(wb)} {
+			if err := a.Transform(dst, src, interp.Bilinear); err != nil {
+				t.Fatal(err)
+			}
+			if p, ok := FirstDiff(dst, want); ok {
+				t.Errorf("k=%d %T: differs at %v", k, dst, p)
+			}
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+)
+
+// SnapAlpha hardens the partially transparent pixels of m in place, such
+// as the anti-aliased edges left by Transform or Rotate. Pixels with alpha
+// below lo become fully transparent and pixels with alpha of at least hi
+// become fully opaque, keeping their color. Pixels in between are left
+// alone, so with lo equal to hi every pixel ends up either transparent or
+// opaque.
+func SnapAlpha(m *image.RGBA, lo, hi uint8) {
+	EachPixel(m, func(_, _ int, c color.RGBA) color.RGBA {
+		a := uint32(c.A)
+		switch {
+		case a < uint32(lo):
+			return color.RGBA{}
+		case a >= uint32(hi) && a != 0xff:
+			return color.RGBA{unpremul(c.R, a), unpremul(c.G, a), unpremul(c.B, a), 0xff}
+		}
+		return c
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestSnapAlpha(t *testing.T) {
+	src := newUniformRGBA(image.Rect(0, 0, 20, 20), color.RGBA{0x40, 0x80, 0xc0, 0xff})
+	dst := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	if err := Rotate(dst, src, &RotateOptions{Angle: 0.5, ThreeShear: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	partial := 0
+	for i := 3; i < len(dst.Pix); i += 4 {
+		if a := dst.Pix[i]; a != 0 && a != 0xff {
+			partial++
+		}
+	}
+	if partial == 0 {
+		t.Fatal("rotation left no partially transparent pixels")
+	}
+
+	SnapAlpha(dst, 0x80, 0x80)
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			c := dst.RGBAAt(x, y)
+			switch c.A {
+			case 0:
+				if c != (color.RGBA{}) {
+					t.Errorf("(%d, %d): transparent pixel has color %v", x, y, c)
+				}
+			case 0xff:
+				// Allow for the rounding of the premultiplied edges.
+				if absDiff(uint32(c.R), 0x40) > 2 || absDiff(uint32(c.G), 0x80) > 2 || absDiff(uint32(c.B), 0xc0) > 2 {
+					t.Errorf("(%d, %d): opaque pixel has color %v", x, y, c)
+				}
+			default:
+				t.Errorf("(%d, %d): alpha 0x%02x", x, y, c.A)
+			}
+		}
+	}
+}
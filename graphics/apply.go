@@ -0,0 +1,76 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"math"
+)
+
+// det returns the determinant of the 2x2 linear part of a.
+func (a Affine) det() float64 {
+	return a[0]*a[4] - a[1]*a[3]
+}
+
+// invert returns the inverse of a, which must have a bottom row of 0 0 1.
+// ok is false if a is singular.
+func (a Affine) invert() (inv Affine, ok bool) {
+	d := a.det()
+	if d == 0 {
+		return Affine{}, false
+	}
+	return Affine{
+		a[4] / d, -a[1] / d, (a[1]*a[5] - a[2]*a[4]) / d,
+		-a[3] / d, a[0] / d, (a[2]*a[3] - a[0]*a[5]) / d,
+		0, 0, 1,
+	}, true
+}
+
+// bounds returns the smallest rectangle of destination pixels whose
+// centers a maps inside src.
+func (a Affine) bounds(src image.Rectangle) (image.Rectangle, error) {
+	if err := a.Valid(); err != nil {
+		return image.Rectangle{}, err
+	}
+	fwd, _ := a.invert()
+	minX, minY := math.Inf(+1), math.Inf(+1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []image.Point{
+		src.Min, {src.Max.X, src.Min.Y}, {src.Min.X, src.Max.Y}, src.Max,
+	} {
+		fx, fy := float64(p.X), float64(p.Y)
+		x := fx*fwd[0] + fy*fwd[1] + fwd[2]
+		y := fx*fwd[3] + fy*fwd[4] + fwd[5]
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+	// Round inwards by less than half a pixel, so that exact edges that
+	// suffer floating point error do not grow the bounds.
+	const e = 1e-6
+	return image.Rect(
+		int(math.Floor(minX+e)), int(math.Floor(minY+e)),
+		int(math.Ceil(maxX-e)), int(math.Ceil(maxY-e)),
+	), nil
+}
+
+// Apply returns a new image holding src transformed by a, using bilinear
+// interpolation. The returned image is sized to exactly contain the
+// transformed bounds of src, in the same co-ordinate space as src, so a
+// pure translation returns src's pixels at the translated position.
+func Apply(src image.Image, a Affine) (image.Image, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	b, err := a.bounds(src.Bounds())
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(b)
+	if err := a.Transform(dst, src, interp.Bilinear); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyTranslate(t *testing.T) {
+	src := NewNoise(6, 4, 1)
+	m, err := Apply(src, I.Translate(3, -2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), image.Rect(3, -2, 9, 2); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 6; x++ {
+			got := color.RGBAModel.Convert(m.At(x+3, y-2))
+			if want := src.RGBAAt(x, y); got != want {
+				t.Fatalf("(%d, %d): got %v want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestApplyScale(t *testing.T) {
+	src := newUniformRGBA(image.Rect(0, 0, 5, 3), color.RGBA{0x10, 0x20, 0x30, 0xff})
+	m, err := Apply(src, I.Scale(2, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := newUniformRGBA(image.Rect(0, 0, 10, 9), color.RGBA{0x10, 0x20, 0x30, 0xff})
+	if err := graphicstest.ImageWithinTolerance(m, want, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplySingular(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	a := Affine{0, 0, 0, 0, 1, 0, 0, 0, 1}
+	if _, err := Apply(src, a); err == nil {
+		t.Error("expected error for singular transform")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+)
+
+// AutoCrop returns the smallest rectangle of src that contains every pixel
+// differing from bg by more than tolerance. Tolerance is the largest
+// allowed per-channel difference, as a fraction in [0, 1].
+//
+// If bg is nil, the background is inferred from the corner pixels of src:
+// the most common corner color is used. If every pixel matches the
+// background, the empty rectangle is returned.
+func AutoCrop(src image.Image, tolerance float64, bg color.Color) image.Rectangle {
+	b := src.Bounds()
+	if b.Empty() {
+		return image.Rectangle{}
+	}
+	if bg == nil {
+		bg = cornerColor(src)
+	}
+	tol := uint32(tolerance * 0xffff)
+	br, bgg, bb, ba := bg.RGBA()
+	isBg := func(x, y int) bool {
+		r, g, b, a := src.At(x, y).RGBA()
+		return absDiff(r, br) <= tol && absDiff(g, bgg) <= tol &&
+			absDiff(b, bb) <= tol && absDiff(a, ba) <= tol
+	}
+	rowIsBg := func(y int) bool {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !isBg(x, y) {
+				return false
+			}
+		}
+		return true
+	}
+	colIsBg := func(x, y0, y1 int) bool {
+		for y := y0; y < y1; y++ {
+			if !isBg(x, y) {
+				return false
+			}
+		}
+		return true
+	}
+
+	r := b
+	for r.Min.Y < r.Max.Y && rowIsBg(r.Min.Y) {
+		r.Min.Y++
+	}
+	if r.Min.Y == r.Max.Y {
+		return image.Rectangle{}
+	}
+	for rowIsBg(r.Max.Y - 1) {
+		r.Max.Y--
+	}
+	for colIsBg(r.Min.X, r.Min.Y, r.Max.Y) {
+		r.Min.X++
+	}
+	for colIsBg(r.Max.X-1, r.Min.Y, r.Max.Y) {
+		r.Max.X--
+	}
+	return r
+}
+
+// AutoCropImage returns a copy of the AutoCrop region of src, translated
+// to the origin.
+func AutoCropImage(src image.Image, tolerance float64, bg color.Color) *image.RGBA {
+	return crop(src, AutoCrop(src, tolerance, bg))
+}
+
+// cornerColor returns the most common color among the corners of src.
+// Ties go to the top-left corner.
+func cornerColor(src image.Image) color.Color {
+	b := src.Bounds()
+	corners := []color.Color{
+		src.At(b.Min.X, b.Min.Y),
+		src.At(b.Max.X-1, b.Min.Y),
+		src.At(b.Min.X, b.Max.Y-1),
+		src.At(b.Max.X-1, b.Max.Y-1),
+	}
+	best, bestN := corners[0], 0
+	for _, c := range corners {
+		n := 0
+		for _, d := range corners {
+			if sameColor(c, d) {
+				n++
+			}
+		}
+		if n > bestN {
+			best, bestN = c, n
+		}
+	}
+	return best
+}
+
+func sameColor(c0, c1 color.Color) bool {
+	r0, g0, b0, a0 := c0.RGBA()
+	r1, g1, b1, a1 := c1.RGBA()
+	return r0 == r1 && g0 == g1 && b0 == b1 && a0 == a1
+}
+
+func absDiff(a, b uint32) uint32 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,49 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestAutoCrop(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.ZP, draw.Src)
+	shape := image.Rect(40, 30, 60, 45)
+	draw.Draw(src, shape, image.NewUniform(color.RGBA{0x20, 0x40, 0x60, 0xff}), image.ZP, draw.Src)
+	// A near-white speck is within tolerance and must not widen the crop.
+	src.SetRGBA(5, 5, color.RGBA{0xfc, 0xfc, 0xfc, 0xff})
+
+	if got := AutoCrop(src, 0.05, color.White); !got.Eq(shape) {
+		t.Errorf("explicit bg: got %v want %v", got, shape)
+	}
+	if got := AutoCrop(src, 0.05, nil); !got.Eq(shape) {
+		t.Errorf("inferred bg: got %v want %v", got, shape)
+	}
+	if got := AutoCrop(src, 0, nil); !got.Eq(image.Rect(5, 5, 60, 45)) {
+		t.Errorf("zero tolerance: got %v want %v", got, image.Rect(5, 5, 60, 45))
+	}
+
+	m := AutoCropImage(src, 0.05, nil)
+	if got, want := m.Bounds(), image.Rect(0, 0, 20, 15); !got.Eq(want) {
+		t.Fatalf("cropped bounds: got %v want %v", got, want)
+	}
+	if got := m.RGBAAt(0, 0); got != (color.RGBA{0x20, 0x40, 0x60, 0xff}) {
+		t.Errorf("cropped pixel: got %v", got)
+	}
+}
+
+func TestAutoCropAllBackground(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	if got := AutoCrop(src, 0, nil); !got.Empty() {
+		t.Errorf("got %v want empty", got)
+	}
+	if got := AutoCropImage(src, 0, nil); !got.Bounds().Empty() {
+		t.Errorf("got %v want empty", got.Bounds())
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Avatarize returns a size by size avatar made from src: its transparent
+// margins are trimmed with TrimAlpha, the rest is cropped to a centered
+// square and scaled with the triangle filter. If bg is not nil, the result
+// is flattened onto it, making it opaque. A fully transparent src gives a
+// transparent avatar, or one filled with bg.
+func Avatarize(src image.Image, size int, bg color.Color) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, max0(size), max0(size)))
+	if size <= 0 {
+		return dst
+	}
+	if trimmed, r := TrimAlpha(src); !r.Empty() {
+		// Crop before scaling, so the filter does not blend in the
+		// pixels just outside the square.
+		n := r.Dx()
+		if r.Dy() < n {
+			n = r.Dy()
+		}
+		x, y := (r.Dx()-n)/2, (r.Dy()-n)/2
+		square := trimmed.SubImage(image.Rect(x, y, x+n, y+n))
+		resample(dst, square, BilinearResampler, nil)
+	}
+	if bg != nil {
+		flat := image.NewRGBA(dst.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(bg), image.ZP, draw.Src)
+		draw.Draw(flat, flat.Bounds(), dst, image.ZP, draw.Over)
+		dst = flat
+	}
+	return dst
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestAvatarize(t *testing.T) {
+	// A 60x20 red bar, with a blue square at its center, padded by
+	// transparency in a 100x100 image.
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	src := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(src, image.Rect(10, 50, 70, 70), image.NewUniform(red), image.ZP, draw.Src)
+	draw.Draw(src, image.Rect(30, 50, 50, 70), image.NewUniform(blue), image.ZP, draw.Src)
+
+	// The square crop of the bar is the blue square, filling the avatar.
+	m := Avatarize(src, 10, nil)
+	if got, want := m.Bounds(), image.Rect(0, 0, 10, 10); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			if got := m.RGBAAt(x, y); got != blue {
+				t.Fatalf("(%d, %d) got %v want %v", x, y, got, blue)
+			}
+		}
+	}
+
+	// A fully transparent source is flattened to the background.
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	m = Avatarize(image.NewRGBA(image.Rect(0, 0, 5, 5)), 4, white)
+	if got := m.RGBAAt(2, 2); got != white {
+		t.Errorf("transparent source: got %v want %v", got, white)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// TransformDirOptions are the parameters of TransformDir.
+// Quality is the JPEG quality, from 1 to 100. If zero, jpeg.DefaultQuality
+// is used.
+type TransformDirOptions struct {
+	Quality int
+}
+
+// BatchError lists the failures of a batch operation, one per file.
+type BatchError []error
+
+func (e BatchError) Error() string {
+	s := make([]string, len(e))
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+	return fmt.Sprintf("graphics: %d files failed: %s", len(e), strings.Join(s, "; "))
+}
+
+// TransformDir loads every JPEG, PNG and GIF file in srcDir, transforms it
+// by a as Apply does, and saves the result to the file of the same name in
+// dstDir, in the same format. Other files and subdirectories are ignored.
+// A file that fails does not stop the others; the failures are returned
+// together as a BatchError.
+func TransformDir(srcDir, dstDir string, a Affine, opt *TransformDirOptions) error {
+	infos, err := ioutil.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+	quality := jpeg.DefaultQuality
+	if opt != nil && opt.Quality != 0 {
+		quality = opt.Quality
+	}
+
+	var errs BatchError
+	for _, fi := range infos {
+		if fi.IsDir() {
+			continue
+		}
+		name := fi.Name()
+		var save func(m image.Image, path string) error
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".jpg", ".jpeg":
+			save = func(m image.Image, path string) error { return SaveJPEG(m, path, quality) }
+		case ".png":
+			save = SavePNG
+		case ".gif":
+			save = func(m image.Image, path string) error { return SaveGIF(m, path, nil) }
+		default:
+			continue
+		}
+
+		src, err := Load(filepath.Join(srcDir, name))
+		if err == nil {
+			var dst image.Image
+			if dst, err = Apply(src, a); err == nil {
+				err = save(dst, filepath.Join(dstDir, name))
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
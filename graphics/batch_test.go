@@ -0,0 +1,60 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+func TestTransformDir(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	if err := SavePNG(NewNoise(20, 10, 1), filepath.Join(srcDir, "a.png")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveJPEG(NewNoise(8, 6, 2), filepath.Join(srcDir, "b.jpg"), 90); err != nil {
+		t.Fatal(err)
+	}
+	// A corrupt image, which fails without stopping the others, and a file
+	// that is not an image, which is ignored.
+	if err := ioutil.WriteFile(filepath.Join(srcDir, "c.png"), []byte("not a png"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(srcDir, "notes.txt"), []byte("hello"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	err := TransformDir(srcDir, dstDir, I.Scale(0.5, 0.5), nil)
+	errs, ok := err.(BatchError)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("got error %v, want one failure", err)
+	}
+
+	for name, want := range map[string]image.Rectangle{
+		"a.png": image.Rect(0, 0, 10, 5),
+		"b.jpg": image.Rect(0, 0, 4, 3),
+	} {
+		m, err := graphicstest.LoadImage(filepath.Join(dstDir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := m.Bounds(); got != want {
+			t.Errorf("%s: bounds %v want %v", name, got, want)
+		}
+	}
+	for _, name := range []string{"c.png", "notes.txt"} {
+		if _, err := os.Stat(filepath.Join(dstDir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: unexpectedly written", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// BilateralFilter produces an edge-preserving smoothed version of src,
+// drawn onto dst. Each pixel is replaced by the average of its neighbours,
+// weighted both by their distance, with the standard deviation
+// sigmaSpatial in pixels, and by their difference in color, with the
+// standard deviation sigmaColor in 8-bit channel units. Neighbours across a
+// strong edge differ greatly in color and contribute little, so the edge
+// stays sharp.
+//
+// The cost is proportional to the number of pixels times sigmaSpatial
+// squared; rows are processed concurrently.
+func BilateralFilter(dst draw.Image, src image.Image, sigmaSpatial, sigmaColor float64) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if sigmaSpatial <= 0 || sigmaColor <= 0 {
+		return errors.New("graphics: bilateral deviations must be positive")
+	}
+
+	s := toRGBA(src)
+	sb := s.Bounds()
+	b := dst.Bounds().Intersect(sb)
+	if b.Empty() {
+		return nil
+	}
+	d, ok := dst.(*image.RGBA)
+	if !ok {
+		d = image.NewRGBA(b)
+	}
+
+	radius := int(math.Ceil(2 * sigmaSpatial))
+	size := 2*radius + 1
+	spatial := make([]float64, size*size)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			d2 := float64(dx*dx + dy*dy)
+			spatial[(dy+radius)*size+dx+radius] = math.Exp(-d2 / (2 * sigmaSpatial * sigmaSpatial))
+		}
+	}
+	// The color weight only depends on the integer squared distance, which
+	// is at most 3*255*255, so tabulate it.
+	colorWeight := make([]float64, 3*255*255+1)
+	for i := range colorWeight {
+		colorWeight[i] = math.Exp(-float64(i) / (2 * sigmaColor * sigmaColor))
+	}
+
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				co := (y-sb.Min.Y)*s.Stride + (x-sb.Min.X)*4
+				cr, cg, cb := int(s.Pix[co]), int(s.Pix[co+1]), int(s.Pix[co+2])
+				var sum [4]float64
+				var wsum float64
+				r := image.Rect(x-radius, y-radius, x+radius+1, y+radius+1).Intersect(sb)
+				for sy := r.Min.Y; sy < r.Max.Y; sy++ {
+					off := (sy-sb.Min.Y)*s.Stride + (r.Min.X-sb.Min.X)*4
+					for sx := r.Min.X; sx < r.Max.X; sx, off = sx+1, off+4 {
+						p := s.Pix[off : off+4]
+						dr, dg, db := int(p[0])-cr, int(p[1])-cg, int(p[2])-cb
+						w := spatial[(sy-y+radius)*size+sx-x+radius] *
+							colorWeight[dr*dr+dg*dg+db*db]
+						sum[0] += w * float64(p[0])
+						sum[1] += w * float64(p[1])
+						sum[2] += w * float64(p[2])
+						sum[3] += w * float64(p[3])
+						wsum += w
+					}
+				}
+				off := (y-d.Rect.Min.Y)*d.Stride + (x-d.Rect.Min.X)*4
+				for c := 0; c < 4; c++ {
+					d.Pix[off+c] = clamp8(sum[c] / wsum)
+				}
+			}
+		}
+	})
+
+	if !ok {
+		draw.Draw(dst, b, d, b.Min, draw.Src)
+	}
+	return nil
+}
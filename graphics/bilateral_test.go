@@ -0,0 +1,72 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// newNoisyStep returns a w by h image, dark on the left half and light on
+// the right, with mild noise added.
+func newNoisyStep(w, h int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 0x30
+			if x >= w/2 {
+				v = 0xd0
+			}
+			v += r.Intn(17) - 8
+			m.SetRGBA(x, y, color.RGBA{uint8(v), uint8(v), uint8(v), 0xff})
+		}
+	}
+	return m
+}
+
+// rowStdDev returns the standard deviation of the red channel of m over
+// the columns [x0, x1) of row y.
+func rowStdDev(m *image.RGBA, y, x0, x1 int) float64 {
+	var sum, sum2 float64
+	for x := x0; x < x1; x++ {
+		v := float64(m.RGBAAt(x, y).R)
+		sum += v
+		sum2 += v * v
+	}
+	n := float64(x1 - x0)
+	mean := sum / n
+	return math.Sqrt(math.Max(0, sum2/n-mean*mean))
+}
+
+func TestBilateralFilter(t *testing.T) {
+	src := newNoisyStep(40, 20)
+	dst := image.NewRGBA(src.Bounds())
+	if err := BilateralFilter(dst, src, 2, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	for y := 4; y < 16; y++ {
+		// The edge stays sharp.
+		l, r := dst.RGBAAt(19, y).R, dst.RGBAAt(20, y).R
+		if int(r)-int(l) < 0x80 {
+			t.Errorf("row %d: edge softened to 0x%02x..0x%02x", y, l, r)
+		}
+		// The flat regions are smoothed.
+		if before, after := rowStdDev(src, y, 2, 16), rowStdDev(dst, y, 2, 16); after >= before/2 {
+			t.Errorf("row %d: standard deviation %.1f, was %.1f", y, after, before)
+		}
+	}
+}
+
+func TestBilateralFilterInvalid(t *testing.T) {
+	m := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if err := BilateralFilter(m, m, 0, 10); err == nil {
+		t.Error("expected error for zero spatial deviation")
+	}
+}
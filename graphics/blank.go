@@ -0,0 +1,43 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"math"
+)
+
+// IsBlank reports whether src is nearly uniform: whether the standard
+// deviation of each of its channels is at most tolerance, as a fraction of
+// the full range in [0, 1]. An empty image is blank.
+func IsBlank(src image.Image, tolerance float64) bool {
+	b := src.Bounds()
+	if b.Empty() {
+		return true
+	}
+	// Accumulate each channel's sum and sum of squares, in 8-bit units.
+	var sum, sq [4]float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := src.At(x, y).RGBA()
+			for c, v := range [4]uint32{r, g, bl, a} {
+				f := float64(v >> 8)
+				sum[c] += f
+				sq[c] += f * f
+			}
+		}
+	}
+	n := float64(b.Dx() * b.Dy())
+	limit := tolerance * 0xff
+	for c := range sum {
+		mean := sum[c] / n
+		// Rounding can make the variance of a flat channel slightly
+		// negative.
+		if sd := math.Sqrt(math.Max(sq[c]/n-mean*mean, 0)); sd > limit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestIsBlank(t *testing.T) {
+	solid := newUniformRGBA(image.Rect(0, 0, 30, 20), color.RGBA{0xf0, 0xf0, 0xe8, 0xff})
+	if !IsBlank(solid, 0) {
+		t.Error("solid image not blank")
+	}
+
+	// A scanned blank page: paper with slight noise.
+	page := newUniformRGBA(image.Rect(0, 0, 30, 20), color.RGBA{0xf0, 0xf0, 0xe8, 0xff})
+	rnd := rand.New(rand.NewSource(1))
+	for i := range page.Pix {
+		if i%4 != 3 {
+			page.Pix[i] -= uint8(rnd.Intn(5))
+		}
+	}
+	if !IsBlank(page, 0.02) {
+		t.Error("noisy page not blank at tolerance 0.02")
+	}
+	if IsBlank(page, 0) {
+		t.Error("noisy page blank at tolerance 0")
+	}
+
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsBlank(src, 0.05) {
+		t.Error("gopher blank at tolerance 0.05")
+	}
+}
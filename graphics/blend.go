@@ -0,0 +1,161 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+)
+
+// floatImage is an image with n float64 channels per pixel, at the origin,
+// used where intermediate values can be negative or fractional.
+type floatImage struct {
+	pix  []float64
+	w, h int
+	n    int
+}
+
+func newFloatImage(w, h, n int) *floatImage {
+	return &floatImage{make([]float64, w*h*n), w, h, n}
+}
+
+// floatRGBA returns the channels of m, which must be at the origin, as a
+// floatImage.
+func floatRGBA(m *image.RGBA) *floatImage {
+	b := m.Bounds()
+	f := newFloatImage(b.Dx(), b.Dy(), 4)
+	for y := 0; y < f.h; y++ {
+		row := m.Pix[y*m.Stride : y*m.Stride+f.w*4]
+		for i, v := range row {
+			f.pix[y*f.w*4+i] = float64(v)
+		}
+	}
+	return f
+}
+
+// up is the counterpart of pyrDown: it doubles m to w by h pixels by
+// inserting zeros between the samples and blurring with twice binomial5.
+func (m *floatImage) up(w, h int) *floatImage {
+	tmp := newFloatImage(w, m.h, m.n)
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < w; x++ {
+			for i, k := range binomial5 {
+				if (x+i)%2 != 0 {
+					continue
+				}
+				sx := clampInt((x+i-2)/2, 0, m.w-1)
+				for c := 0; c < m.n; c++ {
+					tmp.pix[(y*w+x)*m.n+c] += 2 * k * m.pix[(y*m.w+sx)*m.n+c]
+				}
+			}
+		}
+	}
+	dst := newFloatImage(w, h, m.n)
+	for y := 0; y < h; y++ {
+		for i, k := range binomial5 {
+			if (y+i)%2 != 0 {
+				continue
+			}
+			sy := clampInt((y+i-2)/2, 0, m.h-1)
+			for j := 0; j < w*m.n; j++ {
+				dst.pix[y*w*m.n+j] += 2 * k * tmp.pix[sy*w*m.n+j]
+			}
+		}
+	}
+	return dst
+}
+
+func clampInt(x, x0, x1 int) int {
+	if x < x0 {
+		return x0
+	}
+	if x > x1 {
+		return x1
+	}
+	return x
+}
+
+// laplacianPyramid returns the Laplacian pyramid of the Gaussian pyramid
+// g: each level is the detail lost by the next, smaller level of g, and the
+// last level is the smallest level of g itself.
+func laplacianPyramid(g []*image.RGBA) []*floatImage {
+	n := len(g)
+	p := make([]*floatImage, n)
+	next := floatRGBA(g[n-1])
+	p[n-1] = next
+	for i := n - 2; i >= 0; i-- {
+		m := floatRGBA(g[i])
+		up := next.up(m.w, m.h)
+		for j := range up.pix {
+			up.pix[j] = m.pix[j] - up.pix[j]
+		}
+		p[i], next = up, m
+	}
+	return p
+}
+
+// PyramidBlend composites a and b seamlessly with multiband blending:
+// both are split into Laplacian pyramids of frequency bands, each band is
+// blended with a correspondingly blurred copy of mask, and the bands are
+// summed back together. Coarse features thus blend over a wide transition
+// and fine detail over a narrow one, hiding the seam. Where mask is white
+// the result is a, and where it is black it is b.
+//
+// a, b and mask must be the same size, otherwise PyramidBlend returns nil.
+// Pixels correspond by their offset from each image's top-left, and the
+// result is at the origin.
+func PyramidBlend(a, b image.Image, mask *image.Gray) *image.RGBA {
+	if a == nil || b == nil || mask == nil {
+		return nil
+	}
+	ab, bb, mb := a.Bounds(), b.Bounds(), mask.Bounds()
+	if ab.Size() != bb.Size() || ab.Size() != mb.Size() {
+		return nil
+	}
+	w, h := ab.Dx(), ab.Dy()
+
+	// Halve the image until the smallest level is at most 3 pixels across.
+	n := 1
+	for sw, sh := w, h; sw > 3 && sh > 3; n++ {
+		sw, sh = (sw+1)/2, (sh+1)/2
+	}
+	ga, gb, gm := GaussianPyramid(a, n), GaussianPyramid(b, n), GaussianPyramid(mask, n)
+	if len(ga) != n || len(gb) != n || len(gm) != n {
+		return nil
+	}
+	la, lb := laplacianPyramid(ga), laplacianPyramid(gb)
+	for i := 0; i < n; i++ {
+		// The mask levels are gray, so red is the weight.
+		m := gm[i]
+		for y := 0; y < la[i].h; y++ {
+			for x := 0; x < la[i].w; x++ {
+				k := float64(m.Pix[y*m.Stride+x*4]) / 0xff
+				for c := 0; c < 4; c++ {
+					j := (y*la[i].w+x)*4 + c
+					la[i].pix[j] = k*la[i].pix[j] + (1-k)*lb[i].pix[j]
+				}
+			}
+		}
+	}
+
+	// Collapse the blended pyramid.
+	r := la[n-1]
+	for i := n - 2; i >= 0; i-- {
+		up := r.up(la[i].w, la[i].h)
+		for j := range up.pix {
+			up.pix[j] += la[i].pix[j]
+		}
+		r = up
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(dst.Pix); i += 4 {
+		a := clamp8(r.pix[i+3])
+		for c := 0; c < 3; c++ {
+			dst.Pix[i+c] = clampAlpha(r.pix[i+c], float64(a))
+		}
+		dst.Pix[i+3] = a
+	}
+	return dst
+}
@@ -0,0 +1,56 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPyramidBlend(t *testing.T) {
+	r := image.Rect(0, 0, 64, 16)
+	a := newUniformRGBA(r, color.RGBA{0xff, 0, 0, 0xff})
+	b := newUniformRGBA(r, color.RGBA{0, 0, 0xff, 0xff})
+	mask := image.NewGray(r)
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 32; x++ {
+			mask.SetGray(x, y, color.Gray{0xff})
+		}
+	}
+
+	m := PyramidBlend(a, b, mask)
+	if m == nil {
+		t.Fatal("got nil")
+	}
+	for y := 0; y < 16; y++ {
+		prev := 0x100
+		for x := 0; x < 64; x++ {
+			c := m.RGBAAt(x, y)
+			if c.A != 0xff || int(c.R)+int(c.B) < 0xfd || int(c.R)+int(c.B) > 0x101 {
+				t.Fatalf("(%d, %d): %v is not a mix of red and blue", x, y, c)
+			}
+			// The red fades out gradually rather than in one step.
+			if int(c.R) > prev {
+				t.Errorf("(%d, %d): red rises from %d to %d", x, y, prev, c.R)
+			}
+			if prev-int(c.R) > 0x40 && prev != 0x100 {
+				t.Errorf("(%d, %d): red drops from %d to %d", x, y, prev, c.R)
+			}
+			prev = int(c.R)
+		}
+		// Far from the seam the images are unchanged.
+		if c := m.RGBAAt(0, y); c.R < 0xfd {
+			t.Errorf("row %d: left edge %v", y, c)
+		}
+		if c := m.RGBAAt(63, y); c.B < 0xfd {
+			t.Errorf("row %d: right edge %v", y, c)
+		}
+	}
+
+	if PyramidBlend(a, b, image.NewGray(image.Rect(0, 0, 8, 8))) != nil {
+		t.Error("expected nil for size mismatch")
+	}
+}
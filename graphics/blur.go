@@ -16,32 +16,216 @@ import (
 var DefaultStdDev = 0.5
 
 // BlurOptions are the blurring parameters.
-// StdDev is the standard deviation of the normal, higher is blurrier.
+// StdDev is the standard deviation of the normal, higher is blurrier. Zero
+// leaves the image unchanged, and a negative value is an error.
 // Size is the size of the kernel. If zero, it is set to Ceil(6 * StdDev).
+// With the default Edge, it is clamped to the larger dimension of the
+// image.
+// StdDevX and StdDevY, if either is non-zero, replace StdDev with separate
+// horizontal and vertical deviations. An axis with a zero deviation is not
+// blurred.
+// Edge is how the pixels beyond the edges of src are treated.
 type BlurOptions struct {
-	StdDev float64
-	Size   int
+	StdDev           float64
+	Size             int
+	StdDevX, StdDevY float64
+	Edge             BlurEdge
+}
+
+// BlurEdge selects how a blur treats the pixels beyond the edges of src.
+type BlurEdge int
+
+const (
+	// BlurRenormalize leaves them out, scaling up the weights of the
+	// pixels inside src, so that a flat image stays flat up to its edges.
+	BlurRenormalize BlurEdge = iota
+	// BlurExtend repeats the nearest edge pixel.
+	BlurExtend
+	// BlurReflect mirrors src about its edges, which suits photographs.
+	BlurReflect
+	// BlurWrap tiles src, which suits seamless textures.
+	BlurWrap
+	// BlurZero treats them as transparent black, which fades the edges.
+	BlurZero
+)
+
+// edge returns the edge mode of opt, which may be nil.
+func (opt *BlurOptions) edge() BlurEdge {
+	if opt == nil {
+		return BlurRenormalize
+	}
+	return opt.Edge
+}
+
+// padMode returns the PadMode that fills the border of src as e treats
+// it. ok is false for BlurRenormalize, which needs no border.
+func (e BlurEdge) padMode() (mode PadMode, ok bool) {
+	switch e {
+	case BlurExtend:
+		return PadEdge, true
+	case BlurReflect:
+		return PadReflect, true
+	case BlurWrap:
+		return PadWrap, true
+	case BlurZero:
+		return PadZero, true
+	}
+	return 0, false
+}
+
+// blurSource returns the image to convolve with k to blur src as opt
+// describes, and the bounds of the result. For an edge mode other than
+// BlurRenormalize, it is src surrounded by a border as wide as the reach
+// of k, in the co-ordinates of src, and the result is confined to b
+// within src.
+func blurSource(src image.Image, b image.Rectangle, opt *BlurOptions, k *convolve.SeparableKernel) (image.Image, image.Rectangle) {
+	mode, ok := opt.edge().padMode()
+	if !ok {
+		return src, b
+	}
+	sb := src.Bounds()
+	rx, ry := len(k.X)/2, len(k.Y)/2
+	p := Pad(src, ry, rx, ry, rx, mode)
+	p.Rect = p.Rect.Add(sb.Min.Sub(image.Pt(rx, ry)))
+	return p, b.Intersect(sb)
 }
 
 // Blur produces a blurred version of the image, using a Gaussian blur.
+// The blur is computed on premultiplied alpha, so the color of transparent
+// pixels does not bleed into the edges of opaque regions. If dst and src
+// are both *image.Gray, only their single channel is blurred.
 func Blur(dst draw.Image, src image.Image, opt *BlurOptions) error {
 	if dst == nil {
-		return errors.New("graphics: dst is nil")
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	k, err := blurKernel(opt, src.Bounds())
+	if err != nil {
+		return err
+	}
+	if len(k.X) == 1 && len(k.Y) == 1 {
+		// A zero deviation leaves src as it is.
+		b := dst.Bounds().Intersect(src.Bounds())
+		draw.Draw(dst, b, src, b.Min, draw.Src)
+		return nil
+	}
+	s, b := blurSource(src, dst.Bounds(), opt, k)
+	if b == dst.Bounds() {
+		return convolve.Convolve(dst, s, k)
+	}
+	if b.Empty() {
+		return nil
+	}
+	buf := image.NewRGBA(b)
+	if err := convolve.Convolve(buf, s, k); err != nil {
+		return err
+	}
+	draw.Draw(dst, b, buf, b.Min, draw.Src)
+	return nil
+}
+
+// blurCancelRows is the height of the bands BlurCancel processes between
+// checks for cancellation.
+const blurCancelRows = 32
+
+// BlurCancel is like Blur, but stops early and returns ErrCanceled once
+// cancel is closed. The image is blurred in bands of rows, checking for
+// cancellation before each band.
+func BlurCancel(dst draw.Image, src image.Image, opt *BlurOptions, cancel <-chan struct{}) error {
+	if dst == nil {
+		return ErrNilDst
 	}
 	if src == nil {
-		return errors.New("graphics: src is nil")
+		return ErrNilSrc
 	}
 
-	sd := DefaultStdDev
-	size := 0
+	k, err := blurKernel(opt, src.Bounds())
+	if err != nil {
+		return err
+	}
+	halo := len(k.Y) / 2
+	src, b := blurSource(src, dst.Bounds(), opt, k)
+	for y := b.Min.Y; y < b.Max.Y; y += blurCancelRows {
+		if canceled(cancel) {
+			return ErrCanceled
+		}
+		r := image.Rect(b.Min.X, y, b.Max.X, y+blurCancelRows).Intersect(b)
+		hr := image.Rect(r.Min.X, r.Min.Y-halo, r.Max.X, r.Max.Y+halo).Intersect(b)
+		buf := newBuffer(dst, src, hr)
+		if err := convolve.Convolve(buf, src, k); err != nil {
+			return err
+		}
+		draw.Draw(dst, r, buf, r.Min, draw.Src)
+	}
+	return nil
+}
 
+// blurStdDevs returns the horizontal and vertical standard deviations and
+// the kernel size described by opt, or an error if any is invalid.
+func blurStdDevs(opt *BlurOptions) (sdx, sdy float64, size int, err error) {
+	sdx, sdy = DefaultStdDev, DefaultStdDev
 	if opt != nil {
-		sd = opt.StdDev
-		size = opt.Size
+		sdx, sdy, size = opt.StdDev, opt.StdDev, opt.Size
+		if opt.StdDevX != 0 || opt.StdDevY != 0 {
+			sdx, sdy = opt.StdDevX, opt.StdDevY
+		}
+	}
+	for _, sd := range []float64{sdx, sdy} {
+		if sd < 0 {
+			return 0, 0, 0, ErrNegativeStdDev
+		}
+		if math.IsNaN(sd) || math.IsInf(sd, 0) {
+			return 0, 0, 0, errors.New("graphics: standard deviation is not finite")
+		}
 	}
+	if size < 0 {
+		return 0, 0, 0, errors.New("graphics: negative kernel size")
+	}
+	return sdx, sdy, size, nil
+}
 
+// blurKernel returns the separable Gaussian kernel described by opt, for
+// blurring an image with bounds b. With BlurRenormalize, the kernel reaches
+// no further than the larger dimension of b, as taps beyond that never fall
+// inside the image and are renormalized away. The other edge modes give
+// those taps values, so the kernel keeps its full reach.
+func blurKernel(opt *BlurOptions, b image.Rectangle) (*convolve.SeparableKernel, error) {
+	sdx, sdy, size, err := blurStdDevs(opt)
+	if err != nil {
+		return nil, err
+	}
+	max := math.MaxInt32
+	if _, ok := opt.edge().padMode(); !ok {
+		max = b.Dx()
+		if b.Dy() > max {
+			max = b.Dy()
+		}
+		if max < 1 {
+			max = 1
+		}
+	}
+	return &convolve.SeparableKernel{
+		X: gaussian(sdx, size, max),
+		Y: gaussian(sdy, size, max),
+	}, nil
+}
+
+// gaussian returns the normalized one dimensional Gaussian kernel with the
+// standard deviation sd. Its length is 2*size+1. If size is zero, it is set
+// to Ceil(6 * sd). The size is at most max. If sd is zero, the kernel is the
+// identity.
+func gaussian(sd float64, size, max int) []float64 {
+	if sd == 0 {
+		return []float64{1}
+	}
 	if size < 1 {
-		size = int(math.Ceil(sd * 6))
+		size = int(math.Min(math.Ceil(sd*6), float64(max)))
+	}
+	if size > max {
+		size = max
 	}
 
 	kernel := make([]float64, 2*size+1)
@@ -60,9 +244,5 @@ func Blur(dst draw.Image, src image.Image, opt *BlurOptions) error {
 	for i, k := range kernel {
 		kernel[i] = k / kSum
 	}
-
-	return convolve.Convolve(dst, src, &convolve.SeparableKernel{
-		X: kernel,
-		Y: kernel,
-	})
+	return kernel
 }
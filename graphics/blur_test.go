@@ -8,6 +8,7 @@ import (
 	"github.com/image-server/graphics-go/graphics/graphicstest"
 	"image"
 	"image/color"
+	"math"
 	"testing"
 
 	_ "image/png"
@@ -16,19 +17,19 @@ import (
 var blurOneColorTests = []transformOneColorTest{
 	{
 		"1x1-blank", 1, 1, 1, 1,
-		&BlurOptions{0.83, 1},
+		&BlurOptions{StdDev: 0.83, Size: 1},
 		[]uint8{0xff},
 		[]uint8{0xff},
 	},
 	{
 		"1x1-spreadblank", 1, 1, 1, 1,
-		&BlurOptions{0.83, 2},
+		&BlurOptions{StdDev: 0.83, Size: 2},
 		[]uint8{0xff},
 		[]uint8{0xff},
 	},
 	{
 		"3x3-blank", 3, 3, 3, 3,
-		&BlurOptions{0.83, 2},
+		&BlurOptions{StdDev: 0.83, Size: 2},
 		[]uint8{
 			0xff, 0xff, 0xff,
 			0xff, 0xff, 0xff,
@@ -42,7 +43,7 @@ var blurOneColorTests = []transformOneColorTest{
 	},
 	{
 		"3x3-dot", 3, 3, 3, 3,
-		&BlurOptions{0.34, 1},
+		&BlurOptions{StdDev: 0.34, Size: 1},
 		[]uint8{
 			0x00, 0x00, 0x00,
 			0x00, 0xff, 0x00,
@@ -56,7 +57,7 @@ var blurOneColorTests = []transformOneColorTest{
 	},
 	{
 		"5x5-dot", 5, 5, 5, 5,
-		&BlurOptions{0.34, 1},
+		&BlurOptions{StdDev: 0.34, Size: 1},
 		[]uint8{
 			0x00, 0x00, 0x00, 0x00, 0x00,
 			0x00, 0x00, 0x00, 0x00, 0x00,
@@ -74,7 +75,7 @@ var blurOneColorTests = []transformOneColorTest{
 	},
 	{
 		"5x5-dot-spread", 5, 5, 5, 5,
-		&BlurOptions{0.85, 1},
+		&BlurOptions{StdDev: 0.85, Size: 1},
 		[]uint8{
 			0x00, 0x00, 0x00, 0x00, 0x00,
 			0x00, 0x00, 0x00, 0x00, 0x00,
@@ -92,7 +93,7 @@ var blurOneColorTests = []transformOneColorTest{
 	},
 	{
 		"4x4-box", 4, 4, 4, 4,
-		&BlurOptions{0.34, 1},
+		&BlurOptions{StdDev: 0.34, Size: 1},
 		[]uint8{
 			0x00, 0x00, 0x00, 0x00,
 			0x00, 0xff, 0xff, 0x00,
@@ -108,7 +109,7 @@ var blurOneColorTests = []transformOneColorTest{
 	},
 	{
 		"5x5-twodots", 5, 5, 5, 5,
-		&BlurOptions{0.34, 1},
+		&BlurOptions{StdDev: 0.34, Size: 1},
 		[]uint8{
 			0x00, 0x00, 0x00, 0x00, 0x00,
 			0x00, 0x00, 0x00, 0x00, 0x00,
@@ -141,6 +142,50 @@ func TestBlurOneColor(t *testing.T) {
 	}
 }
 
+func TestBlurFlat(t *testing.T) {
+	// The kernel is renormalized over the taps inside src, so a flat image
+	// stays exactly flat up to its edges.
+	gray := color.RGBA{0x80, 0x80, 0x80, 0xff}
+	src := newUniformRGBA(image.Rect(0, 0, 23, 17), gray)
+	for _, opt := range []*BlurOptions{{StdDev: 1.1}, {StdDev: 3}, {StdDevX: 2.5}} {
+		dst := image.NewRGBA(src.Bounds())
+		if err := Blur(dst, src, opt); err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+			t.Errorf("%+v: %v", *opt, err)
+		}
+	}
+
+	// A bright stripe along the left edge spreads only inward: at the
+	// edge, the weights of the taps inside src are scaled up to sum to
+	// one, rather than the missing weight going to the center tap.
+	src = image.NewRGBA(image.Rect(0, 0, 23, 17))
+	for y := 0; y < 17; y++ {
+		src.SetRGBA(0, y, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	}
+	opt := &BlurOptions{StdDevX: 1.5}
+	k, err := blurKernel(opt, src.Bounds())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := len(k.X) / 2
+	var in float64
+	for _, w := range k.X[r:] {
+		in += w
+	}
+	dst := image.NewRGBA(src.Bounds())
+	if err := Blur(dst, src, opt); err != nil {
+		t.Fatal(err)
+	}
+	want := int(0xff*k.X[r]/in + 0.5)
+	for y := 0; y < 17; y++ {
+		if got := int(dst.RGBAAt(0, y).R); got < want-1 || got > want+1 {
+			t.Fatalf("stripe row %d: got 0x%02x want 0x%02x", y, got, want)
+		}
+	}
+}
+
 func TestBlurEmpty(t *testing.T) {
 	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
 	if err := Blur(empty, empty, nil); err != nil {
@@ -188,7 +233,7 @@ func benchBlur(b *testing.B, bounds image.Rectangle) {
 
 	b.StartTimer()
 	for i := 0; i < b.N; i++ {
-		Blur(dst, src, &BlurOptions{0.84, 3})
+		Blur(dst, src, &BlurOptions{StdDev: 0.84, Size: 3})
 	}
 }
 
@@ -205,3 +250,234 @@ func BenchmarkBlur400x800x3(b *testing.B) {
 func BenchmarkBlur400x1600x3(b *testing.B) {
 	benchBlur(b, image.Rect(0, 0, 400, 1600))
 }
+
+func TestBlurAlphaEdge(t *testing.T) {
+	b := image.Rect(0, 0, 16, 16)
+	src := image.NewRGBA(b)
+	for y := 4; y < 12; y++ {
+		for x := 4; x < 12; x++ {
+			src.SetRGBA(x, y, color.RGBA{0xff, 0x80, 0x00, 0xff})
+		}
+	}
+
+	// In premultiplied RGBA, a halo-free edge keeps R equal to A.
+	dst := image.NewRGBA(b)
+	if err := Blur(dst, src, &BlurOptions{StdDev: 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := dst.RGBAAt(x, y)
+			if int(c.A)-int(c.R) > 1 {
+				t.Errorf("(%d, %d): darkened edge %v", x, y, c)
+			}
+		}
+	}
+
+	// Non-premultiplied output keeps full red wherever it is visible.
+	ndst := image.NewNRGBA(b)
+	if err := Blur(ndst, src, &BlurOptions{StdDev: 1.5}); err != nil {
+		t.Fatal(err)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := ndst.NRGBAAt(x, y)
+			if c.A >= 0x20 && c.R < 0xf0 {
+				t.Errorf("(%d, %d): darkened edge %v", x, y, c)
+			}
+		}
+	}
+}
+
+func TestBlurPerAxis(t *testing.T) {
+	// A single bright dot in the middle of a dark image.
+	b := image.Rect(0, 0, 31, 31)
+	src := image.NewRGBA(b)
+	for i := 3; i < len(src.Pix); i += 4 {
+		src.Pix[i] = 0xff
+	}
+	src.SetRGBA(15, 15, color.RGBA{0xff, 0xff, 0xff, 0xff})
+
+	dst := image.NewRGBA(b)
+	if err := Blur(dst, src, &BlurOptions{StdDevX: 4, StdDevY: 0}); err != nil {
+		t.Fatal(err)
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := dst.RGBAAt(x, y)
+			if y != 15 && c.R != 0 {
+				t.Fatalf("(%d, %d): smeared vertically: %v", x, y, c)
+			}
+		}
+	}
+	if c := dst.RGBAAt(11, 15); c.R == 0 {
+		t.Errorf("not smeared horizontally: %v", c)
+	}
+
+	// Equal per-axis deviations match the single StdDev.
+	want := image.NewRGBA(b)
+	if err := Blur(want, src, &BlurOptions{StdDev: 1.3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Blur(dst, src, &BlurOptions{StdDevX: 1.3, StdDevY: 1.3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBlurGray(t *testing.T) {
+	g, m := grayGopher(t)
+	opt := &BlurOptions{StdDev: 1.1}
+	gdst := image.NewGray(g.Bounds())
+	if err := Blur(gdst, g, opt); err != nil {
+		t.Fatal(err)
+	}
+	mdst := image.NewRGBA(m.Bounds())
+	if err := Blur(mdst, m, opt); err != nil {
+		t.Fatal(err)
+	}
+	checkGrayParity(t, "Blur", gdst, mdst, 0)
+
+	gdst = image.NewGray(g.Bounds())
+	if err := BlurCancel(gdst, g, opt, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkGrayParity(t, "BlurCancel", gdst, mdst, 0)
+}
+
+func TestBlurValidation(t *testing.T) {
+	src := NewNoise(12, 9, 1)
+	dst := image.NewRGBA(src.Bounds())
+	for _, opt := range []*BlurOptions{
+		{StdDev: -1},
+		{StdDevX: 1, StdDevY: -1},
+		{StdDev: 1, Size: -3},
+		{StdDev: math.NaN()},
+		{StdDev: math.Inf(1)},
+	} {
+		if err := Blur(dst, src, opt); err == nil {
+			t.Errorf("Blur %+v: expected error", *opt)
+		}
+		if err := BlurCancel(dst, src, opt, nil); err == nil {
+			t.Errorf("BlurCancel %+v: expected error", *opt)
+		}
+		if err := FastBlur(dst, src, opt); err == nil {
+			t.Errorf("FastBlur %+v: expected error", *opt)
+		}
+	}
+
+	// A zero deviation copies src.
+	if err := Blur(dst, src, &BlurOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Errorf("zero deviation: %v", err)
+	}
+
+	// Oversized kernels are clamped to the image, which does not change
+	// the result.
+	want := image.NewRGBA(src.Bounds())
+	if err := Blur(want, src, &BlurOptions{StdDev: 4, Size: 12}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Blur(dst, src, &BlurOptions{StdDev: 4, Size: 1 << 40}); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+		t.Errorf("oversized Size: %v", err)
+	}
+	if err := Blur(dst, src, &BlurOptions{StdDev: 1e12}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBlurEdge(t *testing.T) {
+	// A flat gray image with a white column at its right edge.
+	src := newUniformRGBA(image.Rect(10, 10, 30, 30), color.RGBA{0x80, 0x80, 0x80, 0xff})
+	for y := 10; y < 30; y++ {
+		src.SetRGBA(29, y, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	}
+	blur := func(edge BlurEdge) *image.RGBA {
+		// dst reaches beyond src, where the border must not be drawn.
+		dst := newUniformRGBA(image.Rect(0, 0, 40, 40), color.Black)
+		if err := Blur(dst, src, &BlurOptions{StdDev: 2, Edge: edge}); err != nil {
+			t.Fatal(err)
+		}
+		if c := dst.RGBAAt(9, 20); edge != BlurRenormalize && c != (color.RGBA{0, 0, 0, 0xff}) {
+			t.Errorf("edge %d: got %v outside src, want it untouched", edge, c)
+		}
+		return dst
+	}
+
+	// BlurCancel, working in bands, agrees.
+	banded := newUniformRGBA(image.Rect(0, 0, 40, 40), color.Black)
+	if err := BlurCancel(banded, src, &BlurOptions{StdDev: 2, Edge: BlurReflect}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(banded, blur(BlurReflect), 0); err != nil {
+		t.Errorf("BlurCancel: %v", err)
+	}
+
+	// At the left edge, reflecting keeps the brightness, and zeros fade it.
+	reflect, zero := blur(BlurReflect).RGBAAt(10, 20), blur(BlurZero).RGBAAt(10, 20)
+	if reflect != (color.RGBA{0x80, 0x80, 0x80, 0xff}) {
+		t.Errorf("reflect: got %v at the edge, want the gray kept", reflect)
+	}
+	if zero.A >= 0xe0 || zero.R >= 0x70 {
+		t.Errorf("zero: got %v at the edge, want it faded", zero)
+	}
+
+	// Wrapping brings the white column around to the left edge; extending
+	// and renormalizing do not.
+	wrap := blur(BlurWrap).RGBAAt(10, 20)
+	for _, edge := range []BlurEdge{BlurExtend, BlurRenormalize} {
+		if c := blur(edge).RGBAAt(10, 20); c.R >= wrap.R || c.A != 0xff {
+			t.Errorf("edge %d: got %v at the left edge, want gray below wrapped %v", edge, c, wrap)
+		}
+	}
+
+	// Extending repeats the white column, brightening the right edge
+	// more than reflecting does.
+	ext, ref := blur(BlurExtend).RGBAAt(29, 20), blur(BlurReflect).RGBAAt(29, 20)
+	if ext.R <= ref.R {
+		t.Errorf("got %v extended and %v reflected at the right edge, want extended brighter", ext, ref)
+	}
+}
+
+func TestBlurEdgeLargeDeviation(t *testing.T) {
+	// A deviation much larger than the image spreads it thinly: with
+	// BlurZero, each pixel keeps only the share of the Gaussian falling
+	// on the image.
+	src := newUniformRGBA(image.Rect(0, 0, 4, 4), color.White)
+	const sd = 10
+	share := func(x int) float64 {
+		var in, all float64
+		for i := -60; i <= 60; i++ {
+			w := math.Exp(-float64(i*i) / (2 * sd * sd))
+			if j := x + i; j >= 0 && j < 4 {
+				in += w
+			}
+			all += w
+		}
+		return in / all
+	}
+	for _, opt := range []*BlurOptions{
+		{StdDev: sd, Edge: BlurZero},
+		{StdDev: sd, Size: 60, Edge: BlurZero},
+	} {
+		dst := image.NewRGBA(src.Bounds())
+		if err := Blur(dst, src, opt); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				want := 0xff * share(x) * share(y)
+				if got := float64(dst.RGBAAt(x, y).A); math.Abs(got-want) > 1 {
+					t.Errorf("%+v: (%d, %d) got alpha %v want %.1f", *opt, x, y, got, want)
+				}
+			}
+		}
+	}
+}
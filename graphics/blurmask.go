@@ -0,0 +1,55 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+)
+
+// BlurMasked is like Blur, but only blurs src where mask is opaque. Where
+// mask is transparent, or outside its bounds, src is left sharp. Where mask
+// is partially transparent, the blurred and sharp pixels are blended in
+// proportion to its alpha, so there is no seam at the transition.
+func BlurMasked(dst draw.Image, src image.Image, opt *BlurOptions, mask image.Image) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if mask == nil {
+		return Blur(dst, src, opt)
+	}
+
+	b := dst.Bounds()
+	blurred := image.NewRGBA(b)
+	if err := Blur(blurred, src, opt); err != nil {
+		return err
+	}
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, src, b.Min, draw.Src)
+
+	// Interpolate between the sharp and blurred pixels by the mask alpha.
+	mb := mask.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !image.Pt(x, y).In(mb) {
+				continue
+			}
+			_, _, _, ma := mask.At(x, y).RGBA()
+			if ma == 0 {
+				continue
+			}
+			off := (y-b.Min.Y)*out.Stride + (x-b.Min.X)*4
+			for c := 0; c < 4; c++ {
+				s, bl := uint32(out.Pix[off+c]), uint32(blurred.Pix[off+c])
+				out.Pix[off+c] = uint8((s*(0xffff-ma) + bl*ma + 0x7fff) / 0xffff)
+			}
+		}
+	}
+	draw.Draw(dst, b, out, b.Min, draw.Src)
+	return nil
+}
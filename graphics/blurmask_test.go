@@ -0,0 +1,71 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestBlurMasked(t *testing.T) {
+	src := NewNoise(40, 20, 1)
+	b := src.Bounds()
+	opt := &BlurOptions{StdDev: 2}
+
+	// Blur only the right half.
+	mask := image.NewAlpha(b)
+	right := image.Rect(20, 0, 40, 20)
+	draw.Draw(mask, right, image.Opaque, image.ZP, draw.Src)
+
+	dst := image.NewRGBA(b)
+	if err := BlurMasked(dst, src, opt, mask); err != nil {
+		t.Fatal(err)
+	}
+
+	left := image.Rect(0, 0, 20, 20)
+	if err := graphicstest.ImageWithinTolerance(dst.SubImage(left), src.SubImage(left), 0); err != nil {
+		t.Errorf("masked out half changed: %v", err)
+	}
+	blurred := image.NewRGBA(b)
+	if err := Blur(blurred, src, opt); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst.SubImage(right), blurred.SubImage(right), 0); err != nil {
+		t.Errorf("masked in half not blurred: %v", err)
+	}
+}
+
+func TestBlurMaskedPartial(t *testing.T) {
+	// Black on the left, white on the right; blurring spreads the edge.
+	src := image.NewRGBA(image.Rect(0, 0, 20, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 20; x++ {
+			v := uint8(0)
+			if x >= 10 {
+				v = 0xff
+			}
+			src.SetRGBA(x, y, color.RGBA{v, v, v, 0xff})
+		}
+	}
+	mask := image.NewUniform(color.Alpha{0x80})
+	dst := image.NewRGBA(src.Bounds())
+	if err := BlurMasked(dst, src, &BlurOptions{StdDev: 2}, mask); err != nil {
+		t.Fatal(err)
+	}
+	blurred := image.NewRGBA(src.Bounds())
+	Blur(blurred, src, &BlurOptions{StdDev: 2})
+
+	// A half opaque mask gives the average of sharp and blurred.
+	for x := 0; x < 20; x++ {
+		got := int(dst.RGBAAt(x, 1).R)
+		want := (int(src.RGBAAt(x, 1).R) + int(blurred.RGBAAt(x, 1).R)) / 2
+		if d := got - want; d < -1 || d > 1 {
+			t.Errorf("x=%d: got 0x%02x want 0x%02x", x, got, want)
+		}
+	}
+}
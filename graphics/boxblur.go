@@ -0,0 +1,127 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// FastBlur is like Blur, but approximates the Gaussian by three successive
+// box blurs, each computed with a sliding window, so its cost does not
+// depend on the standard deviation. The approximation is close for large
+// deviations. Size and Edge are ignored; edges are extended by repeating
+// the outermost pixels.
+func FastBlur(dst draw.Image, src image.Image, opt *BlurOptions) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	sdx, sdy, _, err := blurStdDevs(opt)
+	if err != nil {
+		return err
+	}
+
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	s := crop(src, b)
+	w, h := b.Dx(), b.Dy()
+	m := newFloatImage(w, h, 4)
+	for i, v := range s.Pix {
+		m.pix[i] = float64(v)
+	}
+	for _, r := range boxRadii(sdx) {
+		m.boxRows(r)
+	}
+	for _, r := range boxRadii(sdy) {
+		m.boxCols(r)
+	}
+	for i, v := range m.pix {
+		s.Pix[i] = clamp8(v)
+	}
+	draw.Draw(dst, b, s, image.Point{}, draw.Src)
+	return nil
+}
+
+// boxRadii returns the radii of the three box blurs whose succession best
+// approximates a Gaussian with the standard deviation sd. The box widths
+// are the odd integers either side of the ideal width, mixed so that the
+// variance of their succession is closest to sd squared.
+func boxRadii(sd float64) [3]int {
+	const n = 3
+	v := 12 * sd * sd
+	wl := int(math.Floor(math.Sqrt(v/n + 1)))
+	if wl%2 == 0 {
+		wl--
+	}
+	wu := wl + 2
+	m := int(math.Floor((v-float64(n*wl*wl+4*n*wl+3*n))/float64(-4*wl-4) + 0.5))
+	var r [3]int
+	for i := range r {
+		if i < m {
+			r[i] = (wl - 1) / 2
+		} else {
+			r[i] = (wu - 1) / 2
+		}
+	}
+	return r
+}
+
+// boxRows replaces each pixel of m with the mean of the 2r+1 pixels of its
+// row centered on it, repeating the pixels at the ends.
+func (m *floatImage) boxRows(r int) {
+	if r < 1 {
+		return
+	}
+	parallelRows(0, m.h, func(y0, y1 int) {
+		line := make([]float64, m.w*m.n)
+		for y := y0; y < y1; y++ {
+			row := m.pix[y*m.w*m.n : (y+1)*m.w*m.n]
+			copy(line, row)
+			boxLine(row, line, m.w, m.n, r)
+		}
+	})
+}
+
+// boxCols is like boxRows, for columns.
+func (m *floatImage) boxCols(r int) {
+	if r < 1 {
+		return
+	}
+	parallelRows(0, m.w, func(x0, x1 int) {
+		in, out := make([]float64, m.h*m.n), make([]float64, m.h*m.n)
+		for x := x0; x < x1; x++ {
+			for y := 0; y < m.h; y++ {
+				copy(in[y*m.n:(y+1)*m.n], m.pix[(y*m.w+x)*m.n:])
+			}
+			boxLine(out, in, m.h, m.n, r)
+			for y := 0; y < m.h; y++ {
+				copy(m.pix[(y*m.w+x)*m.n:(y*m.w+x+1)*m.n], out[y*m.n:])
+			}
+		}
+	})
+}
+
+// boxLine sets out to the box blur of radius r of the l samples of in,
+// each of n channels, with a running sum.
+func boxLine(out, in []float64, l, n, r int) {
+	k := 1 / float64(2*r+1)
+	at := func(i, c int) float64 { return in[clampInt(i, 0, l-1)*n+c] }
+	for c := 0; c < n; c++ {
+		sum := 0.0
+		for i := -r; i <= r; i++ {
+			sum += at(i, c)
+		}
+		for i := 0; i < l; i++ {
+			out[i*n+c] = sum * k
+			sum += at(i+r+1, c) - at(i-r, c)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"math"
+	"testing"
+
+	_ "image/png"
+)
+
+func TestBoxRadii(t *testing.T) {
+	// Small deviations are limited by the odd box widths.
+	for _, sd := range []float64{2.5, 5, 10, 30} {
+		// The variance of a box of width w is (w*w-1)/12, and variances
+		// add up.
+		v := 0.0
+		for _, r := range boxRadii(sd) {
+			w := float64(2*r + 1)
+			v += (w*w - 1) / 12
+		}
+		if got := math.Sqrt(v); math.Abs(got-sd)/sd > 0.1 {
+			t.Errorf("sd %v: boxes have deviation %v", sd, got)
+		}
+	}
+}
+
+func TestFastBlur(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := src.Bounds()
+	for _, sd := range []float64{3, 8} {
+		opt := &BlurOptions{StdDev: sd}
+		want := image.NewRGBA(b)
+		if err := Blur(want, src, opt); err != nil {
+			t.Fatal(err)
+		}
+		got := image.NewRGBA(b)
+		if err := FastBlur(got, src, opt); err != nil {
+			t.Fatal(err)
+		}
+
+		// Compare away from the edges, which the two treat differently.
+		inner := b.Inset(int(4 * sd))
+		var sum, n float64
+		for y := inner.Min.Y; y < inner.Max.Y; y++ {
+			for x := inner.Min.X; x < inner.Max.X; x++ {
+				off := y*got.Stride + x*4
+				for c := 0; c < 4; c++ {
+					sum += math.Abs(float64(got.Pix[off+c]) - float64(want.Pix[off+c]))
+					n++
+				}
+			}
+		}
+		if mean := sum / n; mean > 1 {
+			t.Errorf("sd %v: mean difference %.3f", sd, mean)
+		}
+	}
+}
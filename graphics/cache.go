@@ -0,0 +1,105 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"container/list"
+	"image"
+	"sync"
+)
+
+// Cache is a least recently used cache of images, bounded by the total
+// size of their pixels, for servers that produce the same images
+// repeatedly. Keys are chosen by the caller, typically from a hash of the
+// source content, the operation and its parameters. A Cache is safe for
+// concurrent use. The cached images are shared between callers and must
+// not be modified.
+type Cache struct {
+	maxBytes int
+
+	mu      sync.Mutex
+	size    int
+	lru     *list.List // Of *cacheEntry, most recently used first.
+	entries map[string]*list.Element
+	pending map[string]*cacheCall
+}
+
+type cacheEntry struct {
+	key string
+	m   *image.RGBA
+}
+
+// cacheCall is a computation in progress, which concurrent requests for
+// the same key wait for rather than repeat.
+type cacheCall struct {
+	done chan struct{}
+	m    *image.RGBA
+}
+
+// NewCache returns an empty Cache holding at most maxBytes of pixels.
+func NewCache(maxBytes int) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		pending:  make(map[string]*cacheCall),
+	}
+}
+
+// GetOrCompute returns the image cached under key, or else calls fn to
+// produce it and caches the result, evicting the least recently used
+// images as needed to stay within the budget. Concurrent calls for a key
+// that is being computed wait for that computation rather than calling fn
+// again. Images larger than the whole budget, and nil results, are
+// returned but not cached.
+func (c *Cache) GetOrCompute(key string, fn func() *image.RGBA) *image.RGBA {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cacheEntry).m
+	}
+	if call, ok := c.pending[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.m
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.pending[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, key)
+		c.add(key, call.m)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.m = fn()
+	return call.m
+}
+
+// Len returns the number of cached images.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// add caches m under key, evicting the least recently used images to make
+// room. c.mu must be held.
+func (c *Cache) add(key string, m *image.RGBA) {
+	if m == nil || len(m.Pix) > c.maxBytes {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key, m})
+	c.size += len(m.Pix)
+	for c.size > c.maxBytes {
+		e := c.lru.Back()
+		old := c.lru.Remove(e).(*cacheEntry)
+		delete(c.entries, old.key)
+		c.size -= len(old.m.Pix)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	// Room for three 10x10 images.
+	c := NewCache(3 * 10 * 10 * 4)
+	calls := 0
+	get := func(key string) *image.RGBA {
+		return c.GetOrCompute(key, func() *image.RGBA {
+			calls++
+			return image.NewRGBA(image.Rect(0, 0, 10, 10))
+		})
+	}
+
+	a := get("a")
+	if got := get("a"); got != a || calls != 1 {
+		t.Fatalf("hit: got %p after %d calls, want %p after 1", got, calls, a)
+	}
+	get("b")
+	get("c")
+	if calls != 3 || c.Len() != 3 {
+		t.Fatalf("misses: got %d calls and %d images, want 3 and 3", calls, c.Len())
+	}
+
+	// Using a makes b the least recently used, so d evicts it.
+	get("a")
+	get("d")
+	if c.Len() != 3 {
+		t.Errorf("got %d images want 3", c.Len())
+	}
+	calls = 0
+	get("a")
+	get("c")
+	get("d")
+	if calls != 0 {
+		t.Errorf("got %d calls for cached images, want 0", calls)
+	}
+	get("b")
+	if calls != 1 {
+		t.Errorf("got %d calls for the evicted image, want 1", calls)
+	}
+
+	// An image larger than the budget is returned, but not cached.
+	big := c.GetOrCompute("big", func() *image.RGBA { return image.NewRGBA(image.Rect(0, 0, 40, 40)) })
+	if big == nil || c.Len() != 3 {
+		t.Errorf("too big: got %v and %d images, want the image and 3", big, c.Len())
+	}
+}
+
+func TestCacheConcurrent(t *testing.T) {
+	c := NewCache(1 << 20)
+	var mu sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	got := make([]*image.RGBA, 8)
+	for i := range got {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = c.GetOrCompute("k", func() *image.RGBA {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				<-release
+				return image.NewRGBA(image.Rect(0, 0, 4, 4))
+			})
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+	for i, m := range got {
+		if m == nil || m != got[0] {
+			t.Fatalf("caller %d got %p want %p", i, m, got[0])
+		}
+	}
+	// Each caller either waited for the one computation or found its
+	// result cached.
+	if calls != 1 {
+		t.Errorf("got %d calls want 1", calls)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+)
+
+// ErrCanceled is returned by operations that were stopped by closing their
+// cancel channel.
+var ErrCanceled = errors.New("graphics: operation canceled")
+
+// canceled reports whether cancel has been closed. A nil channel is never
+// closed.
+func canceled(cancel <-chan struct{}) bool {
+	select {
+	case <-cancel:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// cancelingImage closes cancel after n pixels have been written to it.
+type cancelingImage struct {
+	*image.NRGBA
+	n      int
+	cancel chan struct{}
+}
+
+func (m *cancelingImage) Set(x, y int, c color.Color) {
+	m.NRGBA.Set(x, y, c)
+	if m.n--; m.n == 0 {
+		close(m.cancel)
+	}
+}
+
+func TestTransformCancel(t *testing.T) {
+	src := NewNoise(40, 40, 1)
+	a := I.Rotate(0.5).CenterFit(src.Bounds(), src.Bounds())
+
+	// Close the channel part way through the first row.
+	dst := &cancelingImage{image.NewNRGBA(src.Bounds()), 10, make(chan struct{})}
+	if err := a.TransformCancel(dst, src, interp.Bilinear, dst.cancel); err != ErrCanceled {
+		t.Fatalf("got %v want %v", err, ErrCanceled)
+	}
+	// Nothing is written after the row that was in progress.
+	if c := dst.NRGBAAt(20, 39); c.A != 0 {
+		t.Errorf("last row was written after cancellation: %v", c)
+	}
+
+	// The RGBA fast path stops too.
+	cancel := make(chan struct{})
+	close(cancel)
+	if err := a.TransformCancel(image.NewRGBA(src.Bounds()), src, interp.Bilinear, cancel); err != ErrCanceled {
+		t.Errorf("fast path: got %v want %v", err, ErrCanceled)
+	}
+}
+
+func TestBlurCancel(t *testing.T) {
+	src := NewNoise(50, 100, 2)
+	opt := &BlurOptions{StdDev: 1}
+
+	cancel := make(chan struct{})
+	close(cancel)
+	if err := BlurCancel(image.NewRGBA(src.Bounds()), src, opt, cancel); err != ErrCanceled {
+		t.Errorf("got %v want %v", err, ErrCanceled)
+	}
+
+	// Without cancellation, the output matches Blur.
+	want := image.NewRGBA(src.Bounds())
+	if err := Blur(want, src, opt); err != nil {
+		t.Fatal(err)
+	}
+	got := image.NewRGBA(src.Bounds())
+	if err := BlurCancel(got, src, opt, make(chan struct{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResizeCancel(t *testing.T) {
+	src := NewNoise(40, 20, 3)
+	dst := &cancelingImage{image.NewNRGBA(image.Rect(0, 0, 30, 30)), 5, make(chan struct{})}
+	for _, mode := range []FillMode{Stretch, Fit, Cover} {
+		cancel := make(chan struct{})
+		close(cancel)
+		if err := ResizeCancel(dst, src, &ResizeOptions{Mode: mode}, cancel); err != ErrCanceled {
+			t.Errorf("mode %d: got %v want %v", mode, err, ErrCanceled)
+		}
+	}
+	if err := ResizeCancel(dst, src, nil, dst.cancel); err != ErrCanceled {
+		t.Errorf("mid-operation: got %v want %v", err, ErrCanceled)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// CartoonOptions are the parameters of Cartoon.
+type CartoonOptions struct {
+	// SigmaSpatial and SigmaColor are the deviations of the bilateral
+	// filter used to flatten regions. See BilateralFilter.
+	SigmaSpatial, SigmaColor float64
+
+	// EdgeThreshold is the Sobel gradient magnitude of the smoothed luma
+	// above which a pixel is drawn as an outline. The magnitude ranges
+	// from 0 to about 1440.
+	EdgeThreshold float64
+}
+
+// DefaultCartoonOptions are the options used by Cartoon in place of nil or
+// zero fields.
+var DefaultCartoonOptions = CartoonOptions{
+	SigmaSpatial:  3,
+	SigmaColor:    30,
+	EdgeThreshold: 150,
+}
+
+// Cartoon produces a stylized version of src, drawn onto dst. Regions of
+// similar color are flattened with a bilateral filter and strong edges are
+// outlined in black. Zero fields of opt, or a nil opt, take their values from
+// DefaultCartoonOptions.
+func Cartoon(dst draw.Image, src image.Image, opt *CartoonOptions) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	o := DefaultCartoonOptions
+	if opt != nil {
+		if opt.SigmaSpatial > 0 {
+			o.SigmaSpatial = opt.SigmaSpatial
+		}
+		if opt.SigmaColor > 0 {
+			o.SigmaColor = opt.SigmaColor
+		}
+		if opt.EdgeThreshold > 0 {
+			o.EdgeThreshold = opt.EdgeThreshold
+		}
+	}
+
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	d := image.NewRGBA(b)
+	if err := BilateralFilter(d, src, o.SigmaSpatial, o.SigmaColor); err != nil {
+		return err
+	}
+
+	w, h := b.Dx(), b.Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := d.Pix[y*d.Stride+x*4:]
+			lum[y*w+x] = luma(p[0], p[1], p[2])
+		}
+	}
+	gx, gy := sobel(lum, w, h)
+	for i := range lum {
+		if math.Hypot(gx[i], gy[i]) > o.EdgeThreshold {
+			p := d.Pix[(i/w)*d.Stride+(i%w)*4:]
+			// Black keeps the pixel's alpha, which premultiplied is
+			// just zero color.
+			p[0], p[1], p[2] = 0, 0, 0
+		}
+	}
+
+	draw.Draw(dst, b, d, b.Min, draw.Src)
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"testing"
+)
+
+func TestCartoon(t *testing.T) {
+	src := newNoisyStep(40, 20)
+	dst := image.NewRGBA(src.Bounds())
+	if err := Cartoon(dst, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for y := 0; y < 20; y++ {
+		// The edge between the halves is outlined in black.
+		if l, r := dst.RGBAAt(19, y), dst.RGBAAt(20, y); l.R != 0 && r.R != 0 {
+			t.Errorf("row %d: no outline, got 0x%02x 0x%02x", y, l.R, r.R)
+		}
+		if a := dst.RGBAAt(19, y).A; a != 0xff {
+			t.Errorf("row %d: outline alpha 0x%02x", y, a)
+		}
+		// The interiors are flattened and free of outlines.
+		for _, x0 := range []int{4, 24} {
+			if s := rowStdDev(dst, y, x0, x0+12); s > 2 {
+				t.Errorf("row %d, x %d: standard deviation %.1f", y, x0, s)
+			}
+			for x := x0; x < x0+12; x++ {
+				if dst.RGBAAt(x, y).R == 0 {
+					t.Errorf("row %d: spurious outline at x %d", y, x)
+				}
+			}
+		}
+	}
+}
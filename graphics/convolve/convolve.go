@@ -29,10 +29,10 @@ type Kernel interface {
 	Weights() []float64
 }
 
-// SeparableKernel is a linearly separable, square convolution kernel.
-// X and Y are the per-axis weights. Each slice must be the same length, and
-// have an odd length. The middle element of each slice is the weight for the
-// central pixel. For example, the horizontal Sobel kernel is:
+// SeparableKernel is a linearly separable convolution kernel.
+// X and Y are the per-axis weights. Each slice must have an odd length, but
+// the lengths may differ. The middle element of each slice is the weight for
+// the central pixel. For example, the horizontal Sobel kernel is:
 //	sobelX := &SeparableKernel{
 //		X: []float64{-1, 0, +1},
 //		Y: []float64{1, 2, 1},
@@ -41,12 +41,18 @@ type SeparableKernel struct {
 	X, Y []float64
 }
 
+// Weights returns the square matrix of weights. If X and Y differ in length,
+// the shorter axis is padded with zero weights.
 func (k *SeparableKernel) Weights() []float64 {
 	n := len(k.X)
+	if len(k.Y) > n {
+		n = len(k.Y)
+	}
+	ox, oy := (n-len(k.X))/2, (n-len(k.Y))/2
 	w := make([]float64, n*n)
-	for y := 0; y < n; y++ {
-		for x := 0; x < n; x++ {
-			w[y*n+x] = k.X[x] * k.Y[y]
+	for y := range k.Y {
+		for x := range k.X {
+			w[(y+oy)*n+x+ox] = k.X[x] * k.Y[y]
 		}
 	}
 	return w
@@ -76,124 +82,227 @@ func NewKernel(w []float64) (Kernel, error) {
 	return fullKernel(w), nil
 }
 
-func convolveRGBASep(dst *image.RGBA, src image.Image, k *SeparableKernel) error {
-	if len(k.X) != len(k.Y) {
-		return fmt.Errorf("graphics: kernel not square (x %d, y %d)", len(k.X), len(k.Y))
+// edgeWeights describes how a one dimensional kernel is adjusted near the
+// edge of the source, where some of its taps fall outside.
+type edgeWeights struct {
+	// total is the sum of all the weights.
+	total float64
+	// renorm is whether the weights are non-negative, as for a smoothing
+	// kernel. If so, the weights of the taps inside the source are scaled
+	// up to sum to total, so a flat image stays flat up to its edges.
+	// Otherwise, such as for a derivative kernel, the weights of the taps
+	// outside are given to the central pixel.
+	renorm bool
+}
+
+func newEdgeWeights(k []float64) edgeWeights {
+	e := edgeWeights{renorm: true}
+	for _, w := range k {
+		e.total += w
+		if w < 0 {
+			e.renorm = false
+		}
+	}
+	return e
+}
+
+// convolve1D returns the convolution of the n four-channel samples at, of
+// which those from lo to hi are inside the source, with the kernel k
+// centered on sample c.
+func convolve1D(k []float64, e edgeWeights, c, lo, hi int, at func(i int) [4]float64) (sum [4]float64) {
+	if c < lo || c >= hi {
+		return sum
+	}
+	r := len(k) / 2
+	used, missing := 0.0, 0.0
+	for i, w := range k {
+		j := c + i - r
+		if j < lo || j >= hi {
+			missing += w
+			continue
+		}
+		p := at(j)
+		for ch := range sum {
+			sum[ch] += p[ch] * w
+		}
+		used += w
+	}
+	if missing == 0 {
+		return sum
+	}
+	if e.renorm {
+		if used != 0 {
+			for ch := range sum {
+				sum[ch] *= e.total / used
+			}
+		}
+		return sum
+	}
+	p := at(c)
+	for ch := range sum {
+		sum[ch] += p[ch] * missing
 	}
+	return sum
+}
+
+func convolveRGBASep(dst *image.RGBA, src image.Image, k *SeparableKernel, bias float64) error {
 	if len(k.X)%2 != 1 {
 		return fmt.Errorf("graphics: kernel length (%d) not odd", len(k.X))
 	}
-	radius := (len(k.X) - 1) / 2
-
-	// buf holds the result of vertically blurring src.
-	bounds := dst.Bounds()
-	width, height := bounds.Dx(), bounds.Dy()
-	buf := make([]float64, width*height*4)
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			var r, g, b, a float64
-			// k0 is the kernel weight for the center pixel. This may be greater
-			// than kernel[0], near the boundary of the source image, to avoid
-			// vignetting.
-			k0 := k.X[radius]
-
-			// Add the pixels from above.
-			for i := 1; i <= radius; i++ {
-				f := k.Y[radius-i]
-				if y-i < bounds.Min.Y {
-					k0 += f
-				} else {
-					or, og, ob, oa := src.At(x, y-i).RGBA()
-					r += float64(or>>8) * f
-					g += float64(og>>8) * f
-					b += float64(ob>>8) * f
-					a += float64(oa>>8) * f
-				}
+	if len(k.Y)%2 != 1 {
+		return fmt.Errorf("graphics: kernel length (%d) not odd", len(k.Y))
+	}
+	rx := (len(k.X) - 1) / 2
+	ex, ey := newEdgeWeights(k.X), newEdgeWeights(k.Y)
+
+	// buf holds the result of vertically blurring src, over the columns of
+	// src that the horizontal pass reads.
+	bounds, sb := dst.Bounds(), src.Bounds()
+	x0, x1 := bounds.Min.X-rx, bounds.Max.X+rx
+	if x0 < sb.Min.X {
+		x0 = sb.Min.X
+	}
+	if x1 > sb.Max.X {
+		x1 = sb.Max.X
+	}
+	if x1 < x0 {
+		x1 = x0
+	}
+	bw, height := x1-x0, bounds.Dy()
+	buf := make([]float64, bw*height*4)
+	parallelRows(bounds.Min.Y, bounds.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				p := convolve1D(k.Y, ey, y, sb.Min.Y, sb.Max.Y, func(i int) [4]float64 {
+					r, g, b, a := src.At(x, i).RGBA()
+					return [4]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8), float64(a >> 8)}
+				})
+				copy(buf[((y-bounds.Min.Y)*bw+x-x0)*4:], p[:])
 			}
+		}
+	})
 
-			// Add the pixels from below.
-			for i := 1; i <= radius; i++ {
-				f := k.Y[radius+i]
-				if y+i >= bounds.Max.Y {
-					k0 += f
-				} else {
-					or, og, ob, oa := src.At(x, y+i).RGBA()
-					r += float64(or>>8) * f
-					g += float64(og>>8) * f
-					b += float64(ob>>8) * f
-					a += float64(oa>>8) * f
-				}
+	// dst holds the result of horizontally blurring buf.
+	parallelRows(0, height, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := buf[y*bw*4:]
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				p := convolve1D(k.X, ex, x, x0, x1, func(i int) [4]float64 {
+					o := (i - x0) * 4
+					return [4]float64{row[o], row[o+1], row[o+2], row[o+3]}
+				})
+
+				// Write to dst, clamping to the range [0, 255]. Note that y
+				// is relative to bounds.Min.
+				dstOff := y*dst.Stride + (x-bounds.Min.X)*4
+				dst.Pix[dstOff+0] = uint8(clamp(p[0]+bias+0.5, 0, 255))
+				dst.Pix[dstOff+1] = uint8(clamp(p[1]+bias+0.5, 0, 255))
+				dst.Pix[dstOff+2] = uint8(clamp(p[2]+bias+0.5, 0, 255))
+				dst.Pix[dstOff+3] = uint8(clamp(p[3]+bias+0.5, 0, 255))
 			}
+		}
+	})
+
+	return nil
+}
 
-			// Add the central pixel.
-			or, og, ob, oa := src.At(x, y).RGBA()
-			r += float64(or>>8) * k0
-			g += float64(og>>8) * k0
-			b += float64(ob>>8) * k0
-			a += float64(oa>>8) * k0
-
-			// Write to buf.
-			o := (y-bounds.Min.Y)*width*4 + (x-bounds.Min.X)*4
-			buf[o+0] = r
-			buf[o+1] = g
-			buf[o+2] = b
-			buf[o+3] = a
+// convolveGraySep is like convolveRGBASep for a single channel.
+func convolveGraySep(dst *image.Gray, src *image.Gray, k *SeparableKernel, bias float64) error {
+	if len(k.X)%2 != 1 {
+		return fmt.Errorf("graphics: kernel length (%d) not odd", len(k.X))
+	}
+	if len(k.Y)%2 != 1 {
+		return fmt.Errorf("graphics: kernel length (%d) not odd", len(k.Y))
+	}
+	rx := (len(k.X) - 1) / 2
+	ex, ey := newEdgeWeights(k.X), newEdgeWeights(k.Y)
+
+	bounds, sb := dst.Bounds(), src.Bounds()
+	x0, x1 := bounds.Min.X-rx, bounds.Max.X+rx
+	if x0 < sb.Min.X {
+		x0 = sb.Min.X
+	}
+	if x1 > sb.Max.X {
+		x1 = sb.Max.X
+	}
+	if x1 < x0 {
+		x1 = x0
+	}
+	bw := x1 - x0
+	buf := make([]float64, bw*bounds.Dy())
+	parallelRows(bounds.Min.Y, bounds.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				p := convolve1D(k.Y, ey, y, sb.Min.Y, sb.Max.Y, func(i int) [4]float64 {
+					return [4]float64{float64(src.Pix[src.PixOffset(x, i)])}
+				})
+				buf[(y-bounds.Min.Y)*bw+x-x0] = p[0]
+			}
+		}
+	})
+
+	parallelRows(bounds.Min.Y, bounds.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := buf[(y-bounds.Min.Y)*bw:]
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				p := convolve1D(k.X, ex, x, x0, x1, func(i int) [4]float64 {
+					return [4]float64{row[i-x0]}
+				})
+				dst.Pix[dst.PixOffset(x, y)] = uint8(clamp(p[0]+bias+0.5, 0, 255))
+			}
 		}
+	})
+	return nil
+}
+
+// convolveGray is like convolveRGBA for a single channel.
+func convolveGray(dst *image.Gray, src *image.Gray, k Kernel, bias float64) error {
+	bs := src.Bounds()
+	w := k.Weights()
+	size, err := kernelSize(w)
+	if err != nil {
+		return err
 	}
+	radius := (size - 1) / 2
+	e := newEdgeWeights(w)
 
-	// dst holds the result of horizontally blurring buf.
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			var r, g, b, a float64
-			k0, off := k.X[radius], y*width*4+x*4
-
-			// Add the pixels from the left.
-			for i := 1; i <= radius; i++ {
-				f := k.X[radius-i]
-				if x-i < 0 {
-					k0 += f
-				} else {
-					o := off - i*4
-					r += buf[o+0] * f
-					g += buf[o+1] * f
-					b += buf[o+2] * f
-					a += buf[o+3] * f
+	b := dst.Bounds()
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if !image.Pt(x, y).In(bs) {
+					continue
 				}
-			}
 
-			// Add the pixels from the right.
-			for i := 1; i <= radius; i++ {
-				f := k.X[radius+i]
-				if x+i >= width {
-					k0 += f
-				} else {
-					o := off + i*4
-					r += buf[o+0] * f
-					g += buf[o+1] * f
-					b += buf[o+2] * f
-					a += buf[o+3] * f
+				var v, used, adj float64
+				for cy := y - radius; cy <= y+radius; cy++ {
+					for cx := x - radius; cx <= x+radius; cx++ {
+						factor := w[(cy-y+radius)*size+cx-x+radius]
+						if !image.Pt(cx, cy).In(bs) {
+							adj += factor
+						} else {
+							v += float64(src.Pix[src.PixOffset(cx, cy)]) * factor
+							used += factor
+						}
+					}
 				}
-			}
 
-			// Add the central pixel.
-			r += buf[off+0] * k0
-			g += buf[off+1] * k0
-			b += buf[off+2] * k0
-			a += buf[off+3] * k0
-
-			// Write to dst, clamping to the range [0, 255].
-			dstOff := (y-dst.Rect.Min.Y)*dst.Stride + (x-dst.Rect.Min.X)*4
-			dst.Pix[dstOff+0] = uint8(clamp(r+0.5, 0, 255))
-			dst.Pix[dstOff+1] = uint8(clamp(g+0.5, 0, 255))
-			dst.Pix[dstOff+2] = uint8(clamp(b+0.5, 0, 255))
-			dst.Pix[dstOff+3] = uint8(clamp(a+0.5, 0, 255))
+				if adj != 0 && e.renorm {
+					if used != 0 {
+						v *= e.total / used
+					}
+				} else if adj != 0 {
+					v += float64(src.Pix[src.PixOffset(x, y)]) * adj
+				}
+				dst.Pix[dst.PixOffset(x, y)] = uint8(clamp(v+bias+0.5, 0, 0xff))
+			}
 		}
-	}
+	})
 
 	return nil
 }
 
-func convolveRGBA(dst *image.RGBA, src image.Image, k Kernel) error {
+func convolveRGBA(dst *image.RGBA, src image.Image, k Kernel, bias float64) error {
 	b := dst.Bounds()
 	bs := src.Bounds()
 	w := k.Weights()
@@ -202,53 +311,82 @@ func convolveRGBA(dst *image.RGBA, src image.Image, k Kernel) error {
 		return err
 	}
 	radius := (size - 1) / 2
+	e := newEdgeWeights(w)
 
-	for y := b.Min.Y; y < b.Max.Y; y++ {
-		for x := b.Min.X; x < b.Max.X; x++ {
-			if !image.Pt(x, y).In(bs) {
-				continue
-			}
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if !image.Pt(x, y).In(bs) {
+					continue
+				}
 
-			var r, g, b, a, adj float64
-			for cy := y - radius; cy <= y+radius; cy++ {
-				for cx := x - radius; cx <= x+radius; cx++ {
-					factor := w[(cy-y+radius)*size+cx-x+radius]
-					if !image.Pt(cx, cy).In(bs) {
-						adj += factor
-					} else {
-						sr, sg, sb, sa := src.At(cx, cy).RGBA()
-						r += float64(sr>>8) * factor
-						g += float64(sg>>8) * factor
-						b += float64(sb>>8) * factor
-						a += float64(sa>>8) * factor
+				var r, g, b, a, used, adj float64
+				for cy := y - radius; cy <= y+radius; cy++ {
+					for cx := x - radius; cx <= x+radius; cx++ {
+						factor := w[(cy-y+radius)*size+cx-x+radius]
+						if !image.Pt(cx, cy).In(bs) {
+							adj += factor
+						} else {
+							sr, sg, sb, sa := src.At(cx, cy).RGBA()
+							r += float64(sr>>8) * factor
+							g += float64(sg>>8) * factor
+							b += float64(sb>>8) * factor
+							a += float64(sa>>8) * factor
+							used += factor
+						}
 					}
 				}
-			}
 
-			if adj != 0 {
-				sr, sg, sb, sa := src.At(x, y).RGBA()
-				r += float64(sr>>8) * adj
-				g += float64(sg>>8) * adj
-				b += float64(sb>>8) * adj
-				a += float64(sa>>8) * adj
-			}
+				if adj != 0 && e.renorm {
+					if used != 0 {
+						f := e.total / used
+						r, g, b, a = r*f, g*f, b*f, a*f
+					}
+				} else if adj != 0 {
+					sr, sg, sb, sa := src.At(x, y).RGBA()
+					r += float64(sr>>8) * adj
+					g += float64(sg>>8) * adj
+					b += float64(sb>>8) * adj
+					a += float64(sa>>8) * adj
+				}
 
-			off := (y-dst.Rect.Min.Y)*dst.Stride + (x-dst.Rect.Min.X)*4
-			dst.Pix[off+0] = uint8(clamp(r+0.5, 0, 0xff))
-			dst.Pix[off+1] = uint8(clamp(g+0.5, 0, 0xff))
-			dst.Pix[off+2] = uint8(clamp(b+0.5, 0, 0xff))
-			dst.Pix[off+3] = uint8(clamp(a+0.5, 0, 0xff))
+				off := (y-dst.Rect.Min.Y)*dst.Stride + (x-dst.Rect.Min.X)*4
+				dst.Pix[off+0] = uint8(clamp(r+bias+0.5, 0, 0xff))
+				dst.Pix[off+1] = uint8(clamp(g+bias+0.5, 0, 0xff))
+				dst.Pix[off+2] = uint8(clamp(b+bias+0.5, 0, 0xff))
+				dst.Pix[off+3] = uint8(clamp(a+bias+0.5, 0, 0xff))
+			}
 		}
-	}
+	})
 
 	return nil
 }
 
 // Convolve produces dst by applying the convolution kernel k to src.
-func Convolve(dst draw.Image, src image.Image, k Kernel) (err error) {
+// Near the edges of src, a kernel with no negative weights is renormalized
+// over the taps that fall inside src, so the edges keep the brightness of
+// the interior. For other kernels, the weights of the taps outside src are
+// given to the central pixel. If dst and src are both *image.Gray, the
+// convolution is done on their single channel. The rows of dst are
+// computed in parallel, up to the limit set by SetMaxWorkers.
+func Convolve(dst draw.Image, src image.Image, k Kernel) error {
 	if dst == nil || src == nil || k == nil {
 		return nil
 	}
+	return convolve(dst, src, k, 0)
+}
+
+// convolve is like Convolve, adding bias to each result.
+func convolve(dst draw.Image, src image.Image, k Kernel, bias float64) (err error) {
+	// Gray fast path: a single channel in and out.
+	if dstGray, ok := dst.(*image.Gray); ok {
+		if srcGray, ok := src.(*image.Gray); ok {
+			if k, ok := k.(*SeparableKernel); ok {
+				return convolveGraySep(dstGray, srcGray, k, bias)
+			}
+			return convolveGray(dstGray, srcGray, k, bias)
+		}
+	}
 
 	b := dst.Bounds()
 	dstRgba, ok := dst.(*image.RGBA)
@@ -258,9 +396,9 @@ func Convolve(dst draw.Image, src image.Image, k Kernel) (err error) {
 
 	switch k := k.(type) {
 	case *SeparableKernel:
-		err = convolveRGBASep(dstRgba, src, k)
+		err = convolveRGBASep(dstRgba, src, k, bias)
 	default:
-		err = convolveRGBA(dstRgba, src, k)
+		err = convolveRGBA(dstRgba, src, k, bias)
 	}
 
 	if err != nil {
@@ -272,3 +410,131 @@ func Convolve(dst draw.Image, src image.Image, k Kernel) (err error) {
 	}
 	return nil
 }
+
+// Channel is a set of the channels of a color, as a bit mask.
+type Channel uint8
+
+const (
+	// The individual channels.
+	Red Channel = 1 << iota
+	Green
+	Blue
+	Alpha
+
+	// AllChannels is the set of every channel.
+	AllChannels = Red | Green | Blue | Alpha
+)
+
+// Options are the optional parameters of ConvolveWithOptions.
+// Channels is the set of channels the kernel is applied to. The other
+// channels are copied from src unchanged. Zero means AllChannels, or just
+// the color channels if Divisor or Bias is set.
+// A selection without Alpha convolves the non-premultiplied colors and
+// premultiplies the results by the unchanged alpha, so they stay valid.
+// Otherwise channels are convolved as premultiplied values, so a selection
+// that includes Alpha but not every color channel can produce colors
+// brighter than their alpha allows.
+// Divisor and Bias give the convolution matrices of image editors such as
+// GIMP, each result being the weighted sum divided by Divisor, plus Bias.
+// A zero Divisor means 1.
+type Options struct {
+	Channels Channel
+	Divisor  float64
+	Bias     float64
+}
+
+// unpremultiply returns src as an opaque image of its non-premultiplied
+// colors. Transparent pixels are black.
+func unpremultiply(src image.Image) *image.RGBA {
+	b := src.Bounds()
+	m := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		p := m.Pix[(y-b.Min.Y)*m.Stride:]
+		for x := b.Min.X; x < b.Max.X; x, p = x+1, p[4:] {
+			r, g, bl, a := src.At(x, y).RGBA()
+			if a != 0 {
+				p[0] = uint8((r * 0xffff / a) >> 8)
+				p[1] = uint8((g * 0xffff / a) >> 8)
+				p[2] = uint8((bl * 0xffff / a) >> 8)
+			}
+			p[3] = 0xff
+		}
+	}
+	return m
+}
+
+// scaleKernel returns k with its weights multiplied by f.
+func scaleKernel(k Kernel, f float64) Kernel {
+	if sk, ok := k.(*SeparableKernel); ok {
+		x := make([]float64, len(sk.X))
+		for i, w := range sk.X {
+			x[i] = w * f
+		}
+		return &SeparableKernel{X: x, Y: sk.Y}
+	}
+	w := k.Weights()
+	fk := make(fullKernel, len(w))
+	for i, v := range w {
+		fk[i] = v * f
+	}
+	return fk
+}
+
+// ConvolveWithOptions is like Convolve but takes optional parameters. A nil
+// opt is equivalent to Convolve.
+func ConvolveWithOptions(dst draw.Image, src image.Image, k Kernel, opt *Options) error {
+	if dst == nil || src == nil || k == nil {
+		return nil
+	}
+	ch := AllChannels
+	var bias float64
+	if opt != nil {
+		scaled := opt.Divisor != 0 && opt.Divisor != 1
+		switch {
+		case opt.Channels != 0:
+			ch = opt.Channels
+		case scaled || opt.Bias != 0:
+			// Offsetting alpha would make opaque images translucent.
+			ch = Red | Green | Blue
+		}
+		if scaled {
+			k = scaleKernel(k, 1/opt.Divisor)
+		}
+		bias = opt.Bias
+	}
+	if ch&AllChannels == AllChannels {
+		return convolve(dst, src, k, bias)
+	}
+
+	b := dst.Bounds()
+	buf := image.NewRGBA(b)
+	s := src
+	if ch&Alpha == 0 {
+		s = unpremultiply(src)
+	}
+	if err := convolve(buf, s, k, bias); err != nil {
+		return err
+	}
+	bs := src.Bounds()
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if !image.Pt(x, y).In(bs) {
+					continue
+				}
+				sr, sg, sb, sa := src.At(x, y).RGBA()
+				p := buf.Pix[(y-b.Min.Y)*buf.Stride+(x-b.Min.X)*4:]
+				for i, v := range [4]uint32{sr, sg, sb, sa} {
+					switch {
+					case ch&(1<<uint(i)) == 0:
+						p[i] = uint8(v >> 8)
+					case ch&Alpha == 0:
+						p[i] = uint8((uint32(p[i])*(sa>>8) + 0x7f) / 0xff)
+					}
+				}
+			}
+		}
+	})
+	draw.Draw(dst, b, buf, b.Min, draw.Src)
+	return nil
+}
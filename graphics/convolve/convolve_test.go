@@ -7,6 +7,8 @@ package convolve
 import (
 	"github.com/image-server/graphics-go/graphics/graphicstest"
 	"image"
+	"image/color"
+	"image/draw"
 	"reflect"
 	"testing"
 
@@ -76,3 +78,289 @@ func TestConvolveEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestConvolveSepOffset(t *testing.T) {
+	src, err := graphicstest.LoadImage("../../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &SeparableKernel{
+		X: []float64{0.25, 0.5, 0.25},
+		Y: []float64{0.25, 0.5, 0.25},
+	}
+
+	whole := image.NewRGBA(src.Bounds())
+	if err := Convolve(whole, src, k); err != nil {
+		t.Fatal(err)
+	}
+
+	// A dst that does not start at the origin sees the same source pixels,
+	// except at its edges, where the kernel is truncated.
+	r := image.Rect(20, 30, 60, 80)
+	part := image.NewRGBA(r)
+	if err := Convolve(part, src, k); err != nil {
+		t.Fatal(err)
+	}
+	inner := r.Inset(1)
+	err = graphicstest.ImageWithinTolerance(part.SubImage(inner), whole.SubImage(inner), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSeparableWeightsUneven(t *testing.T) {
+	k := &SeparableKernel{
+		X: []float64{1, 2, 1},
+		Y: []float64{1},
+	}
+	want := []float64{
+		0, 0, 0,
+		1, 2, 1,
+		0, 0, 0,
+	}
+	if w := k.Weights(); !reflect.DeepEqual(w, want) {
+		t.Errorf("got %v want %v", w, want)
+	}
+}
+
+func TestConvolveChannels(t *testing.T) {
+	// Opaque stripes on a transparent background.
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x += 2 {
+			o := y*src.Stride + x*4
+			copy(src.Pix[o:o+4], []uint8{0x20, 0x40, 0x60, 0xff})
+		}
+	}
+	k := &SeparableKernel{
+		X: []float64{0.25, 0.5, 0.25},
+		Y: []float64{1},
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	if err := ConvolveWithOptions(dst, src, k, &Options{Channels: Alpha}); err != nil {
+		t.Fatal(err)
+	}
+	blurred := false
+	for i := 0; i < len(dst.Pix); i += 4 {
+		if !reflect.DeepEqual(dst.Pix[i:i+3], src.Pix[i:i+3]) {
+			t.Fatalf("pixel %d: RGB changed from %v to %v", i/4, src.Pix[i:i+3], dst.Pix[i:i+3])
+		}
+		if dst.Pix[i+3] != src.Pix[i+3] {
+			blurred = true
+		}
+	}
+	if !blurred {
+		t.Error("alpha was not blurred")
+	}
+
+	// All channels is the same as Convolve.
+	want := image.NewRGBA(src.Bounds())
+	if err := Convolve(want, src, k); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvolveWithOptions(dst, src, k, &Options{Channels: AllChannels}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dst.Pix, want.Pix) {
+		t.Error("all channels differs from Convolve")
+	}
+}
+
+func TestConvolveDivisorBias(t *testing.T) {
+	// An emboss matrix, as pasted into GIMP's convolution matrix dialog
+	// with a divisor of 4 and an offset of 128.
+	k, err := NewKernel([]float64{
+		-1, -1, 0,
+		-1, 0, 1,
+		0, 1, 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := &Options{Channels: Red | Green | Blue, Divisor: 4, Bias: 128}
+
+	// A vertical step from dark to light, which the emboss lights up, and
+	// flat regions, which it makes mid-gray.
+	src := image.NewRGBA(image.Rect(0, 0, 6, 6))
+	g := image.NewGray(src.Bounds())
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			v := uint8(0x40)
+			if x >= 3 {
+				v = 0xc0
+			}
+			copy(src.Pix[src.PixOffset(x, y):], []uint8{v, v, v, 0xff})
+			g.Pix[g.PixOffset(x, y)] = v
+		}
+	}
+	// The sum is -2 times the left neighbor plus 2 times the right.
+	want := []uint8{128, 128, 192, 192, 128, 128}
+
+	dst := image.NewRGBA(src.Bounds())
+	if err := ConvolveWithOptions(dst, src, k, opt); err != nil {
+		t.Fatal(err)
+	}
+	gdst := image.NewGray(g.Bounds())
+	if err := ConvolveWithOptions(gdst, g, k, &Options{Divisor: 4, Bias: 128}); err != nil {
+		t.Fatal(err)
+	}
+	for y := 1; y < 5; y++ {
+		for x, v := range want {
+			if got := dst.RGBAAt(x, y); got != (color.RGBA{v, v, v, 0xff}) {
+				t.Errorf("(%d, %d) got %v want %d", x, y, got, v)
+			}
+			if got := gdst.GrayAt(x, y).Y; got != v {
+				t.Errorf("gray (%d, %d) got %d want %d", x, y, got, v)
+			}
+		}
+	}
+
+	// By default the divisor and bias leave alpha alone, and keep the
+	// colors within it: opaque stays opaque, transparent stays
+	// transparent, and a translucent flat region is mid-gray at its
+	// alpha.
+	for _, tt := range []struct {
+		opt     *Options
+		in, out color.RGBA
+	}{
+		{&Options{Divisor: 4, Bias: 128}, color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBA{0x80, 0x80, 0x80, 0xff}},
+		{&Options{Divisor: 4, Bias: 128}, color.RGBA{}, color.RGBA{}},
+		{&Options{Channels: Red | Green | Blue, Bias: 128}, color.RGBA{}, color.RGBA{}},
+		{&Options{Divisor: 4, Bias: 128}, color.RGBA{0x40, 0x40, 0x40, 0x80}, color.RGBA{0x40, 0x40, 0x40, 0x80}},
+	} {
+		src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		draw.Draw(src, src.Bounds(), image.NewUniform(tt.in), image.ZP, draw.Src)
+		dst := image.NewRGBA(src.Bounds())
+		if err := ConvolveWithOptions(dst, src, k, tt.opt); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				if got := dst.RGBAAt(x, y); got != tt.out {
+					t.Fatalf("%+v on %v: (%d, %d) got %v want %v", *tt.opt, tt.in, x, y, got, tt.out)
+				}
+			}
+		}
+	}
+}
+
+func TestConvolveGray(t *testing.T) {
+	src, err := graphicstest.LoadImage("../../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := src.Bounds()
+	g := image.NewGray(b)
+	draw.Draw(g, b, src, b.Min, draw.Src)
+	m := image.NewRGBA(b)
+	draw.Draw(m, b, g, b.Min, draw.Src)
+
+	full, err := NewKernel([]float64{
+		-1, 0, 1,
+		-2, 0, 2,
+		-1, 0, 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sep := &SeparableKernel{
+		X: []float64{1, 2, 1},
+		Y: []float64{0.25, 0.5, 0.25},
+	}
+	for _, k := range []Kernel{full, sep} {
+		gdst := image.NewGray(b)
+		if err := Convolve(gdst, g, k); err != nil {
+			t.Fatal(err)
+		}
+		mdst := image.NewRGBA(b)
+		if err := Convolve(mdst, m, k); err != nil {
+			t.Fatal(err)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if got, want := gdst.GrayAt(x, y).Y, mdst.RGBAAt(x, y).R; got != want {
+					t.Fatalf("%T: (%d, %d) got %d want %d", k, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+// noise returns a w by h image of pseudo-random opaque colors.
+func noise(w, h int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	v := uint32(1)
+	for i := range m.Pix {
+		v = v*1664525 + 1013904223
+		m.Pix[i] = uint8(v >> 24)
+		if i%4 == 3 {
+			m.Pix[i] = 0xff
+		}
+	}
+	return m
+}
+
+// boxKernel returns the full box kernel of the given odd size.
+func boxKernel(size int) Kernel {
+	w := make([]float64, size*size)
+	for i := range w {
+		w[i] = 1 / float64(size*size)
+	}
+	k, _ := NewKernel(w)
+	return k
+}
+
+func TestConvolveWorkers(t *testing.T) {
+	defer SetMaxWorkers(0)
+	src := noise(37, 23)
+	g := image.NewGray(src.Bounds())
+	draw.Draw(g, g.Bounds(), src, image.Point{}, draw.Src)
+	sep := &SeparableKernel{X: []float64{1, 2, 1, 2, 1}, Y: []float64{-1, 0, 1}}
+	for _, k := range []Kernel{sep, boxKernel(5)} {
+		var want, wantGray []byte
+		for _, n := range []int{1, 2, 7} {
+			SetMaxWorkers(n)
+			// A view not at the origin, reaching past src.
+			dst := image.NewRGBA(image.Rect(-3, 4, 30, 40))
+			if err := Convolve(dst, src, k); err != nil {
+				t.Fatal(err)
+			}
+			gdst := image.NewGray(dst.Bounds())
+			if err := Convolve(gdst, g, k); err != nil {
+				t.Fatal(err)
+			}
+			if want == nil {
+				want, wantGray = dst.Pix, gdst.Pix
+				continue
+			}
+			if !reflect.DeepEqual(dst.Pix, want) {
+				t.Errorf("%T n=%d: output differs from a single worker", k, n)
+			}
+			if !reflect.DeepEqual(gdst.Pix, wantGray) {
+				t.Errorf("%T n=%d: gray output differs from a single worker", k, n)
+			}
+		}
+	}
+}
+
+func benchConvolve(b *testing.B, workers int) {
+	defer SetMaxWorkers(0)
+	SetMaxWorkers(workers)
+	src := noise(400, 400)
+	dst := image.NewRGBA(src.Bounds())
+	k := boxKernel(9)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Convolve(dst, src, k)
+	}
+}
+
+func BenchmarkConvolve9x9OneWorker(b *testing.B) {
+	benchConvolve(b, 1)
+}
+
+// Using every CPU, for comparison with the single worker.
+func BenchmarkConvolve9x9(b *testing.B) {
+	benchConvolve(b, 0)
+}
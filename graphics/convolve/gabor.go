@@ -0,0 +1,55 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convolve
+
+import (
+	"errors"
+	"math"
+)
+
+// GaborKernel returns the real, even Gabor kernel, which responds to
+// stripes of the given wavelength, in pixels, whose normal points along
+// orientation, in radians clockwise from the x axis. Orientation 0 picks
+// out vertical stripes. The kernel is a cosine wave of that wavelength
+// and direction under a Gaussian envelope of standard deviation sigma
+// across the stripes and sigma/aspect along them, so a larger aspect is
+// more selective of orientation. It reaches three deviations from its
+// center. Its weights sum to zero, so flat regions give no response, and
+// their absolute values sum to one, so a response is at most the
+// amplitude of the stripes; use a Bias to see negative responses.
+func GaborKernel(wavelength, orientation, sigma, aspect float64) (Kernel, error) {
+	if !(wavelength > 0) || !(sigma > 0) || !(aspect > 0) {
+		return nil, errors.New("graphics: Gabor parameters must be positive")
+	}
+	r := int(math.Ceil(3 * sigma * math.Max(1, 1/aspect)))
+	n := 2*r + 1
+	sin, cos := math.Sincos(orientation)
+	w, env := make([]float64, n*n), make([]float64, n*n)
+	var sum, envSum float64
+	for y := -r; y <= r; y++ {
+		for x := -r; x <= r; x++ {
+			// Rotate into the frame of the stripes.
+			u := float64(x)*cos + float64(y)*sin
+			v := -float64(x)*sin + float64(y)*cos
+			i := (y+r)*n + x + r
+			env[i] = math.Exp(-(u*u + aspect*aspect*v*v) / (2 * sigma * sigma))
+			w[i] = env[i] * math.Cos(2*math.Pi*u/wavelength)
+			sum += w[i]
+			envSum += env[i]
+		}
+	}
+
+	// Remove the sum in proportion to the envelope, which keeps the kernel
+	// local, then normalize.
+	var abs float64
+	for i := range w {
+		w[i] -= sum * env[i] / envSum
+		abs += math.Abs(w[i])
+	}
+	for i := range w {
+		w[i] /= abs
+	}
+	return fullKernel(w), nil
+}
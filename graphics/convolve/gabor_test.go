@@ -0,0 +1,79 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convolve
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+// grating returns a gray image of sinusoidal stripes of the given
+// wavelength whose normal points along orientation, around mid-gray.
+func grating(wavelength, orientation float64) *image.Gray {
+	m := image.NewGray(image.Rect(0, 0, 64, 64))
+	sin, cos := math.Sincos(orientation)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			u := float64(x)*cos + float64(y)*sin
+			m.Pix[y*m.Stride+x] = uint8(128 + 64*math.Cos(2*math.Pi*u/wavelength))
+		}
+	}
+	return m
+}
+
+// gaborEnergy returns the mean absolute response of k to m, away from the
+// edges.
+func gaborEnergy(t *testing.T, k Kernel, m *image.Gray) float64 {
+	dst := image.NewGray(m.Bounds())
+	if err := ConvolveWithOptions(dst, m, k, &Options{Bias: 128}); err != nil {
+		t.Fatal(err)
+	}
+	var sum float64
+	for y := 16; y < 48; y++ {
+		for x := 16; x < 48; x++ {
+			sum += math.Abs(float64(dst.Pix[y*dst.Stride+x]) - 128)
+		}
+	}
+	return sum / (32 * 32)
+}
+
+func TestGaborKernel(t *testing.T) {
+	const wavelength, orientation = 8, math.Pi / 4
+	k, err := GaborKernel(wavelength, orientation, 4, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := k.Weights()
+	var sum, abs float64
+	for _, v := range w {
+		sum += v
+		abs += math.Abs(v)
+	}
+	if math.Abs(sum) > 1e-9 || math.Abs(abs-1) > 1e-9 {
+		t.Errorf("got weights summing to %g, absolute values to %g; want 0 and 1", sum, abs)
+	}
+
+	match := gaborEnergy(t, k, grating(wavelength, orientation))
+	if match < 20 {
+		t.Errorf("got response %.2f to the matching grating, want a strong one", match)
+	}
+	for _, g := range []struct{ wavelength, orientation float64 }{
+		{4, orientation},
+		{16, orientation},
+		{wavelength, orientation + math.Pi/4},
+		{wavelength, orientation + math.Pi/2},
+		{wavelength, 0},
+	} {
+		if e := gaborEnergy(t, k, grating(g.wavelength, g.orientation)); e >= match/2 {
+			t.Errorf("wavelength %v orientation %.2f: got response %.2f, want well below %.2f",
+				g.wavelength, g.orientation, e, match)
+		}
+	}
+
+	if _, err := GaborKernel(0, 0, 1, 1); err == nil {
+		t.Error("zero wavelength: got no error")
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package convolve
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// maxWorkers is the concurrency limit set by SetMaxWorkers. Zero means
+// runtime.GOMAXPROCS.
+var maxWorkers int32
+
+// SetMaxWorkers limits the number of goroutines used by each convolution
+// to n. If n is zero or negative, the limit is reset to the default of
+// runtime.GOMAXPROCS(0). The output is identical regardless of the limit.
+// The graphics package's SetMaxWorkers also sets this limit.
+func SetMaxWorkers(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt32(&maxWorkers, int32(n))
+}
+
+// workers returns the current concurrency limit.
+func workers() int {
+	if n := int(atomic.LoadInt32(&maxWorkers)); n > 0 {
+		return n
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// parallelRows splits the rows [y0, y1) into contiguous bands and calls fn
+// on each band concurrently, returning once every band is done. fn must only
+// write to the rows it is given.
+func parallelRows(y0, y1 int, fn func(y0, y1 int)) {
+	n := workers()
+	rows := y1 - y0
+	if n > rows {
+		n = rows
+	}
+	if n <= 1 {
+		fn(y0, y1)
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			fn(y0+rows*i/n, y0+rows*(i+1)/n)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,38 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+)
+
+// CrossFade returns the linear blend of a and b at the mix t, from 0 for a
+// to 1 for b. The images must be the same size; the result has the bounds
+// of a, and pixels correspond by their offset from each image's top-left.
+// The blend is of premultiplied colors, so a transparent pixel contributes
+// no color.
+func CrossFade(a, b image.Image, t float64) (*image.RGBA, error) {
+	if a == nil || b == nil {
+		return nil, ErrNilSrc
+	}
+	if t < 0 || t > 1 {
+		return nil, errors.New("graphics: mix is outside [0, 1]")
+	}
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Size() != bb.Size() {
+		return nil, ErrSizeMismatch
+	}
+
+	ma, mb := crop(a, ab), crop(b, bb)
+	dst := image.NewRGBA(ab)
+	parallelRows(0, ab.Dy(), func(y0, y1 int) {
+		for i := y0 * dst.Stride; i < y1*dst.Stride; i++ {
+			pa, pb := float64(ma.Pix[i]), float64(mb.Pix[i])
+			dst.Pix[i] = clamp8(pa + (pb-pa)*t)
+		}
+	})
+	return dst, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"testing"
+)
+
+func TestCrossFade(t *testing.T) {
+	a := NewNoise(13, 7, 1)
+	// b is offset from the origin; pixels pair up by position.
+	b := NewNoise(13, 7, 2)
+	b.Rect = b.Rect.Add(image.Pt(5, 9))
+
+	for _, tt := range []struct {
+		t    float64
+		want image.Image
+	}{
+		{0, a},
+		{1, b},
+	} {
+		m, err := CrossFade(a, b, tt.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(crop(m, m.Bounds()), crop(tt.want, tt.want.Bounds()), 0); err != nil {
+			t.Errorf("t=%v: %v", tt.t, err)
+		}
+	}
+
+	m, err := CrossFade(a, b, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range m.Pix {
+		if want := uint8((int(a.Pix[i]) + int(b.Pix[i]) + 1) / 2); v != want {
+			t.Fatalf("byte %d: got %d want %d", i, v, want)
+		}
+	}
+
+	if _, err := CrossFade(a, NewNoise(13, 8, 3), 0.5); err == nil {
+		t.Error("expected error for size mismatch")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+)
+
+// diffGain amplifies differences in DiffImage's visualization, so that a
+// difference of a quarter of the channel range already saturates it.
+const diffGain = 4
+
+// heatRamp are the colors of DiffImage's visualization, evenly spaced from
+// no difference to a saturated one.
+var heatRamp = []color.RGBA{
+	{0x00, 0x00, 0x00, 0xff},
+	{0xff, 0x00, 0x00, 0xff},
+	{0xff, 0xff, 0x00, 0xff},
+	{0xff, 0xff, 0xff, 0xff},
+}
+
+// DiffImage returns a visualization of where a and b differ, and their mean
+// absolute difference per channel, from 0 to 255. The images must be the
+// same size; pixels correspond by their offset from each image's top-left,
+// and the visualization has the bounds of a. Each of its pixels is opaque,
+// black where the images agree and ramping through red and yellow to
+// white as the largest channel difference of the premultiplied colors
+// grows, amplified so that small differences stand out.
+func DiffImage(a, b image.Image) (*image.RGBA, float64, error) {
+	if a == nil || b == nil {
+		return nil, 0, ErrNilSrc
+	}
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Size() != bb.Size() {
+		return nil, 0, ErrSizeMismatch
+	}
+	dst := image.NewRGBA(ab)
+	if ab.Empty() {
+		return dst, 0, nil
+	}
+
+	ma, mb := crop(a, ab), crop(b, bb)
+	sums := make([]int, ab.Dy())
+	parallelRows(0, ab.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for i := y * dst.Stride; i < (y+1)*dst.Stride; i += 4 {
+				dmax := 0
+				for c := 0; c < 4; c++ {
+					d := int(ma.Pix[i+c]) - int(mb.Pix[i+c])
+					if d < 0 {
+						d = -d
+					}
+					sums[y] += d
+					if d > dmax {
+						dmax = d
+					}
+				}
+				h := heatColor(float64(dmax*diffGain) / 0xff)
+				dst.Pix[i+0], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = h.R, h.G, h.B, h.A
+			}
+		}
+	})
+
+	var sum int
+	for _, s := range sums {
+		sum += s
+	}
+	return dst, float64(sum) / float64(len(ma.Pix)), nil
+}
+
+// heatColor returns the color of heatRamp at t, clamped to [0, 1].
+func heatColor(t float64) color.RGBA {
+	if t <= 0 {
+		return heatRamp[0]
+	}
+	n := len(heatRamp) - 1
+	if t >= 1 {
+		return heatRamp[n]
+	}
+	i := int(t * float64(n))
+	return lerpColor(heatRamp[i], heatRamp[i+1], t*float64(n)-float64(i))
+}
+
+// FirstDiff returns the first pixel, in row-major order, at which a and b
+// have different colors, and true, or false if they have none. Pixels
+// correspond by their offset from each image's top-left, and the result is
+// in the co-ordinates of a. Colors are compared as 16-bit premultiplied
+// values, so images of different color models can be equal. If the images
+// differ in size, the result is the top-left of a.
+func FirstDiff(a, b image.Image) (image.Point, bool) {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Size() != bb.Size() {
+		return ab.Min, true
+	}
+	d := bb.Min.Sub(ab.Min)
+	for y := ab.Min.Y; y < ab.Max.Y; y++ {
+		for x := ab.Min.X; x < ab.Max.X; x++ {
+			r0, g0, b0, a0 := a.At(x, y).RGBA()
+			r1, g1, b1, a1 := b.At(x+d.X, y+d.Y).RGBA()
+			if r0 != r1 || g0 != g1 || b0 != b1 || a0 != a1 {
+				return image.Pt(x, y), true
+			}
+		}
+	}
+	return image.Point{}, false
+}
@@ -0,0 +1,87 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDiffImage(t *testing.T) {
+	a := NewNoise(20, 10, 1)
+	// The same pixels, elsewhere.
+	b := image.NewRGBA(a.Bounds().Add(image.Pt(5, 7)))
+	copy(b.Pix, a.Pix)
+
+	m, mean, err := DiffImage(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mean != 0 {
+		t.Errorf("identical: got mean %v want 0", mean)
+	}
+	if !m.Bounds().Eq(a.Bounds()) {
+		t.Errorf("got bounds %v want %v", m.Bounds(), a.Bounds())
+	}
+	black := color.RGBA{0, 0, 0, 0xff}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			if c := m.RGBAAt(x, y); c != black {
+				t.Fatalf("identical: (%d, %d) got %v want black", x, y, c)
+			}
+		}
+	}
+
+	// A single differing pixel lights up, and only it.
+	b.SetRGBA(5+3, 7+4, color.RGBA{0, 0, 0, 0xff})
+	a.SetRGBA(3, 4, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	m, mean, err = DiffImage(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 3 * 255.0 / (20 * 10 * 4); mean != want {
+		t.Errorf("got mean %v want %v", mean, want)
+	}
+	if c := m.RGBAAt(3, 4); c != heatRamp[len(heatRamp)-1] {
+		t.Errorf("got %v at the difference, want %v", c, heatRamp[len(heatRamp)-1])
+	}
+	if c := m.RGBAAt(4, 4); c != black {
+		t.Errorf("got %v beside the difference, want black", c)
+	}
+
+	if _, _, err := DiffImage(a, image.NewRGBA(image.Rect(0, 0, 20, 11))); err != ErrSizeMismatch {
+		t.Errorf("sizes differ: got %v want %v", err, ErrSizeMismatch)
+	}
+}
+
+func TestFirstDiff(t *testing.T) {
+	a := NewNoise(20, 10, 1)
+	b := image.NewRGBA(a.Bounds().Add(image.Pt(-3, 2)))
+	copy(b.Pix, a.Pix)
+	if p, ok := FirstDiff(a, b); ok {
+		t.Errorf("identical: got a difference at %v", p)
+	}
+	// The same colors in another color model.
+	n := image.NewNRGBA(a.Bounds())
+	draw.Draw(n, n.Bounds(), a, image.Point{}, draw.Src)
+	if p, ok := FirstDiff(a, n); ok {
+		t.Errorf("NRGBA copy: got a difference at %v", p)
+	}
+
+	// Of two differences, the one in the earlier row comes first.
+	c := b.RGBAAt(-3+7, 2+4)
+	c.G ^= 1
+	b.SetRGBA(-3+7, 2+4, c)
+	b.SetRGBA(-3+15, 2+5, color.RGBA{})
+	if p, ok := FirstDiff(a, b); !ok || p != image.Pt(7, 4) {
+		t.Errorf("got %v, %t want (7,4), true", p, ok)
+	}
+
+	if _, ok := FirstDiff(a, image.NewRGBA(image.Rect(0, 0, 20, 9))); !ok {
+		t.Error("sizes differ: got no difference")
+	}
+}
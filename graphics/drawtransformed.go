@@ -0,0 +1,58 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/draw"
+)
+
+// DrawTransformed composites src, transformed by a, onto dst with the
+// operator op, using bilinear interpolation. As with Transform, a maps dst
+// co-ordinates to src co-ordinates. Unlike Transform, only the pixels of
+// dst covered by the transformed src are touched, and they are combined
+// with the existing contents by op rather than overwritten, so repeated
+// calls build up a scene.
+func DrawTransformed(dst draw.Image, src image.Image, a Affine, op draw.Op) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	sb := src.Bounds()
+	r, err := a.bounds(sb)
+	if err != nil {
+		return err
+	}
+	r = r.Intersect(dst.Bounds())
+	if r.Empty() {
+		return nil
+	}
+
+	buf := image.NewRGBA(r)
+	if err := a.Transform(buf, src, interp.Bilinear); err != nil {
+		return err
+	}
+	// Composite only the pixels that come from within src. The transformed
+	// bounds are convex, so each row has at most one such span.
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		x0, x1 := r.Max.X, r.Min.X
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if sx, sy := a.pt(x, y); inBounds(sb, sx, sy) {
+				if x < x0 {
+					x0 = x
+				}
+				x1 = x + 1
+			}
+		}
+		if x0 < x1 {
+			span := image.Rect(x0, y, x1, y+1)
+			draw.Draw(dst, span, buf, span.Min, op)
+		}
+	}
+	return nil
+}
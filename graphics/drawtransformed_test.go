@@ -0,0 +1,57 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestDrawTransformed(t *testing.T) {
+	bg := color.RGBA{0x10, 0x20, 0x30, 0xff}
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	canvas := newUniformRGBA(image.Rect(0, 0, 60, 40), bg)
+	sprite := newUniformRGBA(image.Rect(0, 0, 10, 10), red)
+
+	// Stamp the sprite turned 45 degrees about its center, centered at
+	// (15, 20) and then at (45, 20). With draw.Src, pixels outside the
+	// rotated sprite must still keep the canvas color.
+	for _, cx := range []float64{15, 45} {
+		a := I.Translate(-5, -5).Rotate(math.Pi/4).Translate(cx, 20)
+		if err := DrawTransformed(canvas, sprite, a, draw.Src); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tt := range []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{15, 20, red}, {45, 20, red},
+		// Within the sprite's square bounds but outside the diamond.
+		{9, 14, bg}, {51, 26, bg},
+		// Between and away from the stamps.
+		{30, 20, bg}, {0, 0, bg}, {59, 39, bg},
+	} {
+		if got := canvas.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("(%d, %d): got %v want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// draw.Over blends with what is already there.
+	blue := newUniformRGBA(image.Rect(0, 0, 4, 4), color.RGBA{0, 0, 0x80, 0x80})
+	if err := DrawTransformed(canvas, blue, I.Translate(13, 18), draw.Over); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := canvas.RGBAAt(15, 20), (color.RGBA{0x7f, 0, 0x80, 0xff}); got != want {
+		t.Errorf("over: got %v want %v", got, want)
+	}
+	if got := canvas.RGBAAt(18, 20); got != red {
+		t.Errorf("over: outside the stamp got %v want %v", got, red)
+	}
+}
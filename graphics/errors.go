@@ -0,0 +1,29 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+)
+
+// Errors returned by the functions of this package, so that callers can
+// tell the failure modes apart. See also ErrCanceled.
+var (
+	// ErrNilDst is returned when the destination image is nil.
+	ErrNilDst = errors.New("graphics: dst is nil")
+	// ErrNilSrc is returned when a source image is nil.
+	ErrNilSrc = errors.New("graphics: src is nil")
+	// ErrEmptySrc is returned by operations that need at least one source
+	// pixel.
+	ErrEmptySrc = errors.New("graphics: src is empty")
+	// ErrSizeMismatch is returned when images that must be the same size
+	// are not.
+	ErrSizeMismatch = errors.New("graphics: images differ in size")
+	// ErrSingularTransform is returned for affine transforms that collapse
+	// an axis, and so have no inverse.
+	ErrSingularTransform = errors.New("graphics: transform is degenerate")
+	// ErrNegativeStdDev is returned for a negative standard deviation.
+	ErrNegativeStdDev = errors.New("graphics: negative standard deviation")
+)
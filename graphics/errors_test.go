@@ -0,0 +1,48 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	m := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	tests := []struct {
+		desc string
+		f    func() error
+		want error
+	}{
+		{"Blur nil dst", func() error { return Blur(nil, m, nil) }, ErrNilDst},
+		{"Blur nil src", func() error { return Blur(m, nil, nil) }, ErrNilSrc},
+		{"Resize nil dst", func() error { return Resize(nil, m, nil) }, ErrNilDst},
+		{"Scale nil src", func() error { return Scale(m, nil) }, ErrNilSrc},
+		{"Rotate nil src", func() error { return Rotate(m, nil, nil) }, ErrNilSrc},
+		{"Thumbnail nil dst", func() error { return Thumbnail(nil, m) }, ErrNilDst},
+		{"Transform nil src", func() error { return I.Transform(m, nil, interp.Bilinear) }, ErrNilSrc},
+		{"Transform singular", func() error { return I.Scale(0, 1).Transform(m, m, interp.Bilinear) }, ErrSingularTransform},
+		{"AffineFromPoints collinear", func() error {
+			_, err := AffineFromPoints([3]image.Point{{0, 0}, {1, 1}, {2, 2}}, [3]image.Point{{0, 0}, {1, 0}, {0, 1}})
+			return err
+		}, ErrSingularTransform},
+		{"CrossFade sizes", func() error {
+			_, err := CrossFade(m, image.NewRGBA(image.Rect(0, 0, 3, 4)), 0.5)
+			return err
+		}, ErrSizeMismatch},
+		{"SeamCarve empty", func() error {
+			_, err := SeamCarve(image.NewRGBA(image.Rectangle{}), 1, 1)
+			return err
+		}, ErrEmptySrc},
+		{"Blur negative deviation", func() error { return Blur(m, m, &BlurOptions{StdDev: -1}) }, ErrNegativeStdDev},
+		{"UnsharpMask negative deviation", func() error { return UnsharpMask(m, m, &UnsharpOptions{StdDev: -1}) }, ErrNegativeStdDev},
+	}
+	for _, tt := range tests {
+		if err := tt.f(); err != tt.want {
+			t.Errorf("%s: got %v want %v", tt.desc, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// jpegSegment is a JPEG marker segment, holding its marker byte and
+// payload (excluding the two length bytes).
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// jpegSegments returns the marker segments that precede the image data of
+// the JPEG b. ok is false if b is not a JPEG.
+func jpegSegments(b []byte) (segs []jpegSegment, ok bool) {
+	if len(b) < 2 || b[0] != 0xff || b[1] != 0xd8 {
+		return nil, false
+	}
+	b = b[2:]
+	for len(b) >= 4 && b[0] == 0xff {
+		marker := b[1]
+		// Start of scan: the entropy coded data follows.
+		if marker == 0xda {
+			break
+		}
+		n := int(b[2])<<8 | int(b[3])
+		if n < 2 || len(b) < 2+n {
+			break
+		}
+		segs = append(segs, jpegSegment{marker, b[4 : 2+n]})
+		b = b[2+n:]
+	}
+	return segs, true
+}
+
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifOrientation returns the EXIF orientation tag, from 1 to 8, of the
+// JPEG b. It returns 1, meaning no transformation, if there is no valid
+// tag.
+func exifOrientation(b []byte) int {
+	segs, _ := jpegSegments(b)
+	for _, s := range segs {
+		if s.marker != 0xe1 || !bytes.HasPrefix(s.data, exifHeader) {
+			continue
+		}
+		if o := tiffOrientation(s.data[len(exifHeader):]); o >= 1 && o <= 8 {
+			return o
+		}
+	}
+	return 1
+}
+
+// tiffOrientation returns the orientation tag (0x0112) of the first IFD of
+// the TIFF structure t, or 0 if there is none.
+func tiffOrientation(t []byte) int {
+	order, e := tiffOrientationEntry(t)
+	if e < 0 {
+		return 0
+	}
+	return int(order.Uint16(t[e+8:]))
+}
+
+// tiffOrientationEntry returns the byte order of the TIFF structure t and
+// the offset of the orientation entry of its first IFD, or -1 if there is
+// none.
+func tiffOrientationEntry(t []byte) (binary.ByteOrder, int) {
+	if len(t) < 8 {
+		return nil, -1
+	}
+	var order binary.ByteOrder
+	switch string(t[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, -1
+	}
+	off := int(order.Uint32(t[4:]))
+	if off < 8 || off+2 > len(t) {
+		return nil, -1
+	}
+	n := int(order.Uint16(t[off:]))
+	for i := 0; i < n; i++ {
+		e := off + 2 + i*12
+		if e+12 > len(t) {
+			return nil, -1
+		}
+		// The tag must be a single SHORT, stored in the value field.
+		if order.Uint16(t[e:]) == 0x0112 && order.Uint16(t[e+2:]) == 3 {
+			return order, e
+		}
+	}
+	return nil, -1
+}
+
+var iccHeader = []byte("ICC_PROFILE\x00")
+
+// maxICCChunk is the most profile data that fits in one APP2 segment,
+// after the header and the chunk sequence number and count.
+const maxICCChunk = 0xffff - 2 - len("ICC_PROFILE\x00") - 2
+
+// jpegMetadata returns the metadata of the JPEG b. If upright is set, the
+// image has been rotated to its EXIF orientation, so the copy of the EXIF
+// segment has its orientation reset to normal.
+func jpegMetadata(b []byte, upright bool) *Metadata {
+	segs, _ := jpegSegments(b)
+	md := &Metadata{}
+	var chunks [][]byte
+	for _, s := range segs {
+		switch {
+		case s.marker == 0xe1 && bytes.HasPrefix(s.data, exifHeader) && md.EXIF == nil:
+			md.EXIF = append([]byte{}, s.data[len(exifHeader):]...)
+			if upright {
+				if order, e := tiffOrientationEntry(md.EXIF); e >= 0 {
+					order.PutUint16(md.EXIF[e+8:], 1)
+				}
+			}
+		case s.marker == 0xe2 && bytes.HasPrefix(s.data, iccHeader) && len(s.data) >= len(iccHeader)+2:
+			// Chunks are numbered from 1; put them in sequence.
+			seq, n := int(s.data[len(iccHeader)]), int(s.data[len(iccHeader)+1])
+			if seq < 1 || seq > n {
+				continue
+			}
+			if chunks == nil {
+				chunks = make([][]byte, n)
+			}
+			if seq <= len(chunks) {
+				chunks[seq-1] = s.data[len(iccHeader)+2:]
+			}
+		}
+	}
+	for _, c := range chunks {
+		if c == nil {
+			// An incomplete profile is worse than none.
+			md.ICC = nil
+			break
+		}
+		md.ICC = append(md.ICC, c...)
+	}
+	return md
+}
+
+// jpegWithMetadata returns the JPEG b with the metadata of md inserted
+// after its start of image marker.
+func jpegWithMetadata(b []byte, md *Metadata) []byte {
+	if md == nil || len(b) < 2 {
+		return b
+	}
+	var seg bytes.Buffer
+	put := func(marker byte, parts ...[]byte) {
+		n := 2
+		for _, p := range parts {
+			n += len(p)
+		}
+		seg.Write([]byte{0xff, marker, byte(n >> 8), byte(n)})
+		for _, p := range parts {
+			seg.Write(p)
+		}
+	}
+	if len(md.EXIF) > 0 && len(md.EXIF) <= 0xffff-2-len(exifHeader) {
+		put(0xe1, exifHeader, md.EXIF)
+	}
+	n := (len(md.ICC) + maxICCChunk - 1) / maxICCChunk
+	if n <= 0xff {
+		for i := 0; i < n; i++ {
+			c := md.ICC[i*maxICCChunk:]
+			if len(c) > maxICCChunk {
+				c = c[:maxICCChunk]
+			}
+			put(0xe2, iccHeader, []byte{byte(i + 1), byte(n)}, c)
+		}
+	}
+	out := make([]byte, 0, len(b)+seg.Len())
+	out = append(out, b[:2]...)
+	out = append(out, seg.Bytes()...)
+	return append(out, b[2:]...)
+}
@@ -0,0 +1,145 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Gamma applies gamma correction to src and draws the result onto dst.
+// Each color channel value v in [0, 1] becomes v^(1/g), so g greater than
+// 1 brightens the mid-tones and g less than 1 darkens them. Alpha is
+// unchanged.
+func Gamma(dst draw.Image, src image.Image, g float64) error {
+	return GammaRGB(dst, src, g, g, g)
+}
+
+// GammaRGB is like Gamma but applies the independent gammas gr, gg and gb
+// to the red, green and blue channels respectively.
+func GammaRGB(dst draw.Image, src image.Image, gr, gg, gb float64) error {
+	return GammaRGBWithOptions(dst, src, gr, gg, gb, nil)
+}
+
+// ToneOptions are optional parameters of the tone operations.
+type ToneOptions struct {
+	// Dither adds a 4x4 ordered (Bayer) dither to each channel before it
+	// is quantized to 8 bits, which breaks up banding in smooth gradients.
+	Dither bool
+}
+
+// GammaRGBWithOptions is like GammaRGB but takes optional parameters. A nil
+// opt is equivalent to GammaRGB.
+func GammaRGBWithOptions(dst draw.Image, src image.Image, gr, gg, gb float64, opt *ToneOptions) error {
+	if gr <= 0 || gg <= 0 || gb <= 0 {
+		return errors.New("graphics: gamma must be positive")
+	}
+	var curve [3][256]float64
+	for c, g := range [3]float64{gr, gg, gb} {
+		for i := range curve[c] {
+			curve[c][i] = 255 * math.Pow(float64(i)/255, 1/g)
+		}
+	}
+	return applyTone(dst, src, &curve, opt != nil && opt.Dither)
+}
+
+// bayer4 is the 4x4 ordered dither matrix.
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// applyTone maps each color channel of src through the corresponding curve
+// and draws the result onto dst. The curves map non-premultiplied 8-bit
+// values to values in [0, 255], which are rounded, or dithered if dither
+// is set. Alpha is unchanged.
+func applyTone(dst draw.Image, src image.Image, curve *[3][256]float64, dither bool) error {
+	return mapPixels(dst, src, func(x, y int, c color.RGBA) color.RGBA {
+		a := uint32(c.A)
+		if a == 0 {
+			return color.RGBA{}
+		}
+		// t is the quantization threshold: 0.5 rounds to nearest, and
+		// the dither spreads it over [0, 1).
+		t := 0.5
+		if dither {
+			t = (bayer4[y&3][x&3] + 0.5) / 16
+		}
+		p := [3]uint8{c.R, c.G, c.B}
+		for i, v := range p {
+			if a != 0xff {
+				v = unpremul(v, a)
+			}
+			p[i] = clamp8(math.Floor(curve[i][v]*float64(a)/0xff + t))
+		}
+		return color.RGBA{p[0], p[1], p[2], c.A}
+	})
+}
+
+// AutoLevels stretches the contrast of src to the full range and draws the
+// result onto dst. The black and white points are the channel values below
+// and above which clipPercent percent of the color channel values of src
+// lie, so that a few outlying pixels do not limit the stretch; those
+// values are clipped to black and white. The same stretch is applied to
+// each channel, so the hues are kept. Transparent pixels are ignored, and
+// alpha is unchanged. clipPercent must be in [0, 50).
+func AutoLevels(dst draw.Image, src image.Image, clipPercent float64) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if !(clipPercent >= 0 && clipPercent < 50) {
+		return errors.New("graphics: clip percentage is outside [0, 50)")
+	}
+
+	// The histogram of the non-premultiplied channel values.
+	s := toRGBA(src)
+	var hist [256]int
+	var n int
+	for y := s.Rect.Min.Y; y < s.Rect.Max.Y; y++ {
+		row := s.Pix[(y-s.Rect.Min.Y)*s.Stride:]
+		for i := 0; i < s.Rect.Dx()*4; i += 4 {
+			a := uint32(row[i+3])
+			if a == 0 {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				v := (uint32(row[i+c])*0xff + a/2) / a
+				if v > 0xff {
+					v = 0xff
+				}
+				hist[v]++
+			}
+			n += 3
+		}
+	}
+
+	// Skip the clipped counts from either end.
+	clip := int(float64(n) * clipPercent / 100)
+	lo, hi := 0, 0xff
+	for sum := hist[lo]; lo < 0xff && sum <= clip; sum += hist[lo] {
+		lo++
+	}
+	for sum := hist[hi]; hi > 0 && sum <= clip; sum += hist[hi] {
+		hi--
+	}
+
+	var curve [3][256]float64
+	for i := range curve[0] {
+		v := float64(i)
+		if hi > lo {
+			v = math.Min(math.Max((v-float64(lo))*0xff/float64(hi-lo), 0), 0xff)
+		}
+		curve[0][i], curve[1][i], curve[2][i] = v, v, v
+	}
+	return applyTone(dst, s, &curve, false)
+}
@@ -0,0 +1,206 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	_ "image/png"
+)
+
+func TestGammaRGB(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		src.SetRGBA(x, 0, color.RGBA{uint8(x), uint8(x), uint8(x), 0xff})
+	}
+	dst := image.NewRGBA(src.Bounds())
+	gammas := [3]float64{0.5, 1, 2.2}
+	if err := GammaRGB(dst, src, gammas[0], gammas[1], gammas[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	for x := 0; x < 256; x++ {
+		c := dst.RGBAAt(x, 0)
+		got := [3]uint8{c.R, c.G, c.B}
+		for i, g := range gammas {
+			want := math.Pow(float64(x)/255, 1/g) * 255
+			if math.Abs(float64(got[i])-want) > 0.5 {
+				t.Errorf("x=%d channel %d: got %d, want %.1f", x, i, got[i], want)
+			}
+		}
+		if c.A != 0xff {
+			t.Errorf("x=%d: alpha changed to %d", x, c.A)
+		}
+	}
+}
+
+func TestGammaRGBEqual(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := src.Bounds()
+	d0, d1 := image.NewRGBA(b), image.NewRGBA(b)
+	if err := Gamma(d0, src, 1.8); err != nil {
+		t.Fatal(err)
+	}
+	if err := GammaRGB(d1, src, 1.8, 1.8, 1.8); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(d0, d1, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGammaInvalid(t *testing.T) {
+	m := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	if err := GammaRGB(m, m, 1, 0, 1); err == nil {
+		t.Error("expected error for zero gamma")
+	}
+}
+
+// longestRun returns the length of the longest run of equal red values in
+// row y of m.
+func longestRun(m *image.RGBA, y int) int {
+	b := m.Bounds()
+	best, n := 0, 0
+	for x := b.Min.X; x < b.Max.X; x++ {
+		if x > b.Min.X && m.RGBAAt(x, y).R == m.RGBAAt(x-1, y).R {
+			n++
+		} else {
+			n = 1
+		}
+		if n > best {
+			best = n
+		}
+	}
+	return best
+}
+
+func TestGammaDither(t *testing.T) {
+	// A shallow gradient where each input level spans 8 columns.
+	src := image.NewRGBA(image.Rect(0, 0, 128, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 128; x++ {
+			v := uint8(96 + x/8)
+			src.SetRGBA(x, y, color.RGBA{v, v, v, 0xff})
+		}
+	}
+	plain, dithered := image.NewRGBA(src.Bounds()), image.NewRGBA(src.Bounds())
+	if err := GammaRGBWithOptions(plain, src, 1.5, 1.5, 1.5, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := GammaRGBWithOptions(dithered, src, 1.5, 1.5, 1.5, &ToneOptions{Dither: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for y := 0; y < 4; y++ {
+		if p, d := longestRun(plain, y), longestRun(dithered, y); d >= p {
+			t.Errorf("row %d: dithered run %d, plain run %d", y, d, p)
+		}
+	}
+
+	// Each 4x4 block of the dithered output averages closer to the ideal
+	// curve than the rounded output.
+	var perr, derr float64
+	for x0 := 0; x0 < 128; x0 += 4 {
+		want := 255 * math.Pow(float64(96+x0/8)/255, 1/1.5)
+		var psum, dsum float64
+		for y := 0; y < 4; y++ {
+			for x := x0; x < x0+4; x++ {
+				psum += float64(plain.RGBAAt(x, y).R)
+				dsum += float64(dithered.RGBAAt(x, y).R)
+			}
+		}
+		perr += math.Abs(psum/16 - want)
+		derr += math.Abs(dsum/16 - want)
+	}
+	if derr >= perr {
+		t.Errorf("dithered error %.2f, plain error %.2f", derr, perr)
+	}
+}
+
+func TestGammaAlpha(t *testing.T) {
+	// Every alpha, with a color that is not already black or white.
+	src := image.NewNRGBA(image.Rect(0, 0, 256, 2))
+	for x := 0; x < 256; x++ {
+		src.SetNRGBA(x, 0, color.NRGBA{0x80, 0x40, 0xc0, uint8(x)})
+		src.SetNRGBA(x, 1, color.NRGBA{0x10, 0xf0, 0x60, uint8(x)})
+	}
+	for _, opt := range []*ToneOptions{nil, {Dither: true}} {
+		for _, dst := range []draw.Image{image.NewRGBA(src.Bounds()), image.NewNRGBA(src.Bounds())} {
+			if err := GammaRGBWithOptions(dst, src, 2.2, 0.5, 1.8, opt); err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < 2; y++ {
+				for x := 0; x < 256; x++ {
+					if _, _, _, a := dst.At(x, y).RGBA(); a>>8 != uint32(x) {
+						t.Fatalf("%T %+v: (%d, %d) got alpha %d want %d", dst, opt, x, y, a>>8, x)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAutoLevels(t *testing.T) {
+	// A low-contrast ramp, from 0x60 to 0xa0, with a few outliers.
+	src := image.NewRGBA(image.Rect(0, 0, 100, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 100; x++ {
+			v := uint8(0x60 + x*0x40/99)
+			src.SetRGBA(x, y, color.RGBA{v, v, v, 0xff})
+		}
+	}
+	src.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	src.SetRGBA(1, 0, color.RGBA{0xff, 0xff, 0xff, 0xff})
+
+	span := func(m *image.RGBA) (lo, hi uint8) {
+		lo, hi = 0xff, 0
+		// Skip the row of outliers.
+		for y := 1; y < 10; y++ {
+			for x := 0; x < 100; x++ {
+				c := m.RGBAAt(x, y)
+				if c.R < lo {
+					lo = c.R
+				}
+				if c.R > hi {
+					hi = c.R
+				}
+			}
+		}
+		return lo, hi
+	}
+
+	// Without clipping, the outliers already span the range, so little
+	// changes.
+	dst := image.NewRGBA(src.Bounds())
+	if err := AutoLevels(dst, src, 0); err != nil {
+		t.Fatal(err)
+	}
+	if lo, hi := span(dst); lo < 0x58 || hi > 0xa8 {
+		t.Errorf("no clipping: got span [%#x, %#x], want about [0x60, 0xa0]", lo, hi)
+	}
+
+	// Clipping 1% ignores them and stretches the ramp.
+	if err := AutoLevels(dst, src, 1); err != nil {
+		t.Fatal(err)
+	}
+	if lo, hi := span(dst); lo > 0x08 || hi < 0xf7 {
+		t.Errorf("1%% clipped: got span [%#x, %#x], want about [0, 0xff]", lo, hi)
+	}
+	if c := dst.RGBAAt(50, 5); c.A != 0xff || c.R < 0x70 || c.R > 0x90 {
+		t.Errorf("got %v mid-ramp, want mid-gray", c)
+	}
+
+	if err := AutoLevels(dst, src, 50); err == nil {
+		t.Error("50% clipped: got no error")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// NewNoise returns a w by h opaque image of uniformly distributed random
+// colors. The same seed always produces the same image.
+func NewNoise(w, h int, seed int64) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < len(m.Pix); i += 4 {
+		v := r.Uint32()
+		m.Pix[i+0] = uint8(v)
+		m.Pix[i+1] = uint8(v >> 8)
+		m.Pix[i+2] = uint8(v >> 16)
+		m.Pix[i+3] = 0xff
+	}
+	return m
+}
+
+// NewGradient returns a w by h image holding a horizontal linear gradient,
+// from the color from in the left column to the color to in the right.
+func NewGradient(w, h int, from, to color.Color) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		t := 0.0
+		if w > 1 {
+			t = float64(x) / float64(w-1)
+		}
+		c := lerpColor(from, to, t)
+		for y := 0; y < h; y++ {
+			m.SetRGBA(x, y, c)
+		}
+	}
+	return m
+}
+
+// LinearGradient returns a w by h image holding a linear gradient running
+// in the direction angle, in radians clockwise from the x axis, from the
+// color from in the corner it starts at to the color to in the opposite
+// corner. Lines perpendicular to angle are a single color. Angle 0 is the
+// same as NewGradient, and math.Pi/2 runs from the top row to the bottom.
+func LinearGradient(w, h int, angle float64, from, to color.Color) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, max0(w), max0(h)))
+	sin, cos := math.Sincos(angle)
+	// Snap the rounding error at multiples of π/2, so that axis-aligned
+	// gradients are uniform across their width.
+	if math.Abs(sin) < 1e-12 {
+		sin = 0
+	}
+	if math.Abs(cos) < 1e-12 {
+		cos = 0
+	}
+	// The projections of the corner pixels onto the direction bound the
+	// range of the gradient.
+	p0, p1 := math.Inf(1), math.Inf(-1)
+	for _, x := range []int{0, w - 1} {
+		for _, y := range []int{0, h - 1} {
+			p := float64(x)*cos + float64(y)*sin
+			p0, p1 = math.Min(p0, p), math.Max(p1, p)
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := 0.0
+			if p1-p0 > 1e-9 {
+				t = (float64(x)*cos + float64(y)*sin - p0) / (p1 - p0)
+			}
+			m.SetRGBA(x, y, lerpColor(from, to, math.Max(0, math.Min(1, t))))
+		}
+	}
+	return m
+}
+
+// RadialGradient returns a w by h image holding a circular gradient, from
+// the color inner at the pixel center to the color outer at the corner
+// pixel farthest from it. The center need not lie within the image.
+func RadialGradient(w, h int, center image.Point, inner, outer color.Color) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, max0(w), max0(h)))
+	dist := func(x, y int) float64 {
+		return math.Hypot(float64(x-center.X), float64(y-center.Y))
+	}
+	r := math.Max(
+		math.Max(dist(0, 0), dist(w-1, 0)),
+		math.Max(dist(0, h-1), dist(w-1, h-1)),
+	)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			t := 0.0
+			if r > 0 {
+				t = math.Min(1, dist(x, y)/r)
+			}
+			m.SetRGBA(x, y, lerpColor(inner, outer, t))
+		}
+	}
+	return m
+}
+
+// lerpColor linearly interpolates between c0 and c1, in premultiplied
+// space. t is in [0, 1].
+func lerpColor(c0, c1 color.Color, t float64) color.RGBA {
+	r0, g0, b0, a0 := c0.RGBA()
+	r1, g1, b1, a1 := c1.RGBA()
+	lerp := func(u0, u1 uint32) uint8 {
+		return uint8((float64(u0)*(1-t)+float64(u1)*t)/0x101 + 0.5)
+	}
+	return color.RGBA{lerp(r0, r1), lerp(g0, g1), lerp(b0, b1), lerp(a0, a1)}
+}
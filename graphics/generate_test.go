@@ -0,0 +1,111 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestNewNoise(t *testing.T) {
+	m0 := NewNoise(32, 16, 1)
+	m1 := NewNoise(32, 16, 1)
+	m2 := NewNoise(32, 16, 2)
+	if got, want := m0.Bounds(), image.Rect(0, 0, 32, 16); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	if !bytes.Equal(m0.Pix, m1.Pix) {
+		t.Error("same seed produced different noise")
+	}
+	if bytes.Equal(m0.Pix, m2.Pix) {
+		t.Error("different seeds produced identical noise")
+	}
+	for i := 3; i < len(m0.Pix); i += 4 {
+		if m0.Pix[i] != 0xff {
+			t.Fatalf("pixel %d not opaque", i/4)
+		}
+	}
+}
+
+func TestNewGradient(t *testing.T) {
+	m := NewGradient(5, 2, color.Black, color.White)
+	want := []uint8{0x00, 0x40, 0x80, 0xbf, 0xff}
+	for y := 0; y < 2; y++ {
+		for x, w := range want {
+			c := m.RGBAAt(x, y)
+			if c.R != w || c.G != w || c.B != w || c.A != 0xff {
+				t.Errorf("(%d, %d): got %v want gray 0x%02x", x, y, c, w)
+			}
+		}
+	}
+}
+
+func TestLinearGradient(t *testing.T) {
+	// Angle 0 is NewGradient.
+	got, want := LinearGradient(5, 2, 0, color.Black, color.White), NewGradient(5, 2, color.Black, color.White)
+	if !bytes.Equal(got.Pix, want.Pix) {
+		t.Errorf("angle 0: got %v want %v", got.Pix, want.Pix)
+	}
+
+	red, blue := color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0, 0xff, 0xff}
+	m := LinearGradient(8, 5, math.Pi/2, red, blue)
+	for x := 0; x < 8; x++ {
+		if got := m.RGBAAt(x, 0); got != red {
+			t.Errorf("top (%d, 0): got %v want %v", x, got, red)
+		}
+		if got := m.RGBAAt(x, 4); got != blue {
+			t.Errorf("bottom (%d, 4): got %v want %v", x, got, blue)
+		}
+		if got, want := m.RGBAAt(x, 2), (color.RGBA{0x80, 0, 0x80, 0xff}); got != want {
+			t.Errorf("middle (%d, 2): got %v want %v", x, got, want)
+		}
+	}
+
+	// A diagonal gradient runs between opposite corners, and is constant
+	// along the other diagonal of a square.
+	m = LinearGradient(9, 9, math.Pi/4, color.Black, color.White)
+	if got := m.RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 0xff}) {
+		t.Errorf("start corner: got %v want black", got)
+	}
+	if got := m.RGBAAt(8, 8); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("end corner: got %v want white", got)
+	}
+	if a, b := m.RGBAAt(8, 0), m.RGBAAt(0, 8); a != b || a != m.RGBAAt(4, 4) {
+		t.Errorf("cross diagonal: got %v, %v and %v, want equal", a, m.RGBAAt(4, 4), b)
+	}
+}
+
+func TestRadialGradient(t *testing.T) {
+	white, black := color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBA{0, 0, 0, 0xff}
+	m := RadialGradient(40, 30, image.Pt(10, 12), white, black)
+	if got := m.RGBAAt(10, 12); got != white {
+		t.Errorf("center: got %v want %v", got, white)
+	}
+	// The farthest corner is the outer color, and the others lie between.
+	if got := m.RGBAAt(39, 29); got != black {
+		t.Errorf("farthest corner: got %v want %v", got, black)
+	}
+	for _, pt := range []image.Point{{0, 0}, {39, 0}, {0, 29}} {
+		if got := m.RGBAAt(pt.X, pt.Y); got.R == 0 || got.R == 0xff {
+			t.Errorf("corner %v: got %v, want between", pt, got)
+		}
+	}
+	// Brightness falls with distance from the center.
+	last := uint8(0xff)
+	for x := 10; x < 40; x++ {
+		got := m.RGBAAt(x, 12).R
+		if got > last {
+			t.Errorf("(%d, 12): got %d, brighter than %d nearer the center", x, got, last)
+		}
+		last = got
+	}
+	// Equal distances give equal colors.
+	if a, b := m.RGBAAt(15, 12), m.RGBAAt(10, 17); a != b {
+		t.Errorf("got %v and %v at equal distances, want equal", a, b)
+	}
+}
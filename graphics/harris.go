@@ -0,0 +1,106 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+)
+
+// harrisK is the sensitivity parameter of the Harris response.
+const harrisK = 0.04
+
+// harrisRadius is the radius of the neighbourhood within which a corner
+// must have the strongest response to be reported.
+const harrisRadius = 3
+
+// HarrisCorners returns the corner features of src found by the Harris
+// detector. The structure tensor of the Sobel gradients of the luma is
+// smoothed over a 5x5 Gaussian window, and a pixel is a corner if its
+// Harris response is at least threshold times the strongest response in the
+// image, from 0 to 1, and is the strongest within 3 pixels. The points are
+// in src's co-ordinates, in row major order.
+func HarrisCorners(src image.Image, threshold float64) []image.Point {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return nil
+	}
+	s := crop(src, b)
+	lum := make([]float64, w*h)
+	for i := range lum {
+		p := s.Pix[i*4:]
+		lum[i] = luma(p[0], p[1], p[2])
+	}
+	gx, gy := sobel(lum, w, h)
+
+	xx, yy, xy := make([]float64, w*h), make([]float64, w*h), make([]float64, w*h)
+	for i := range lum {
+		xx[i], yy[i], xy[i] = gx[i]*gx[i], gy[i]*gy[i], gx[i]*gy[i]
+	}
+	xx, yy, xy = blur5(xx, w, h), blur5(yy, w, h), blur5(xy, w, h)
+
+	resp := lum // No longer needed, so reuse it.
+	max := 0.0
+	for i := range resp {
+		tr := xx[i] + yy[i]
+		resp[i] = xx[i]*yy[i] - xy[i]*xy[i] - harrisK*tr*tr
+		if resp[i] > max {
+			max = resp[i]
+		}
+	}
+	if max <= 0 {
+		return nil
+	}
+
+	var corners []image.Point
+	min := threshold * max
+	for y := 0; y < h; y++ {
+	pixel:
+		for x := 0; x < w; x++ {
+			r := resp[y*w+x]
+			if r <= 0 || r < min {
+				continue
+			}
+			// Suppress all but the strongest response nearby, breaking
+			// ties in favour of the first in row major order.
+			for ny := y - harrisRadius; ny <= y+harrisRadius; ny++ {
+				for nx := x - harrisRadius; nx <= x+harrisRadius; nx++ {
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					n := resp[ny*w+nx]
+					if n > r || n == r && ny*w+nx < y*w+x {
+						continue pixel
+					}
+				}
+			}
+			corners = append(corners, image.Pt(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return corners
+}
+
+// blur5 returns the w*h plane p blurred with the 5-tap binomial kernel,
+// clamping at the edges.
+func blur5(p []float64, w, h int) []float64 {
+	tmp := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for i, k := range binomial5 {
+				tmp[y*w+x] += k * p[y*w+clampInt(x+i-2, 0, w-1)]
+			}
+		}
+	}
+	dst := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for i, k := range binomial5 {
+			row := tmp[clampInt(y+i-2, 0, h-1)*w:]
+			for x := 0; x < w; x++ {
+				dst[y*w+x] += k * row[x]
+			}
+		}
+	}
+	return dst
+}
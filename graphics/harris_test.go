@@ -0,0 +1,55 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestHarrisCorners(t *testing.T) {
+	// A checkerboard of 8 pixel squares, offset from the origin.
+	const n, size = 6, 8
+	r := image.Rect(10, 20, 10+n*size, 20+n*size)
+	src := image.NewRGBA(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.RGBA{0x20, 0x20, 0x20, 0xff}
+			if ((x-r.Min.X)/size+(y-r.Min.Y)/size)%2 == 0 {
+				c = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	corners := HarrisCorners(src, 0.1)
+	// Every corner is near an interior grid intersection, and every
+	// intersection has exactly one corner.
+	found := make(map[image.Point]int)
+	for _, p := range corners {
+		gx := (p.X - r.Min.X + size/2) / size
+		gy := (p.Y - r.Min.Y + size/2) / size
+		q := image.Pt(r.Min.X+gx*size, r.Min.Y+gy*size)
+		if d := p.Sub(q); d.X < -2 || d.X > 2 || d.Y < -2 || d.Y > 2 || gx < 1 || gx >= n || gy < 1 || gy >= n {
+			t.Errorf("corner %v is not near an intersection", p)
+			continue
+		}
+		found[q]++
+	}
+	for gy := 1; gy < n; gy++ {
+		for gx := 1; gx < n; gx++ {
+			q := image.Pt(r.Min.X+gx*size, r.Min.Y+gy*size)
+			if found[q] != 1 {
+				t.Errorf("intersection %v: %d corners", q, found[q])
+			}
+		}
+	}
+
+	// A flat image has no corners.
+	if c := HarrisCorners(newUniformRGBA(r, color.White), 0.1); len(c) != 0 {
+		t.Errorf("flat image: got %v", c)
+	}
+}
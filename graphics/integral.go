@@ -0,0 +1,46 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+)
+
+// IntegralImage returns the summed-area table of src. The table has one
+// more row and column than src: t[y][x] is the sum of the pixels above and
+// to the left of (x, y), relative to src.Bounds().Min, so the first row and
+// column are zero. Use IntegralSum to total any rectangle in constant time.
+func IntegralImage(src *image.Gray) [][]int64 {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	t := make([][]int64, h+1)
+	buf := make([]int64, (h+1)*(w+1))
+	for y := range t {
+		t[y] = buf[y*(w+1) : (y+1)*(w+1)]
+	}
+	for y := 0; y < h; y++ {
+		row := src.Pix[(y+b.Min.Y-src.Rect.Min.Y)*src.Stride+(b.Min.X-src.Rect.Min.X):]
+		var sum int64
+		for x := 0; x < w; x++ {
+			sum += int64(row[x])
+			t[y+1][x+1] = t[y][x+1] + sum
+		}
+	}
+	return t
+}
+
+// IntegralSum returns the sum of the pixels within r of the image whose
+// summed-area table is t. r is relative to the image's bounds' Min and is
+// clipped to the image.
+func IntegralSum(t [][]int64, r image.Rectangle) int64 {
+	if len(t) == 0 {
+		return 0
+	}
+	r = r.Intersect(image.Rect(0, 0, len(t[0])-1, len(t)-1))
+	if r.Empty() {
+		return 0
+	}
+	return t[r.Max.Y][r.Max.X] - t[r.Min.Y][r.Max.X] - t[r.Max.Y][r.Min.X] + t[r.Min.Y][r.Min.X]
+}
@@ -0,0 +1,40 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func TestIntegralSum(t *testing.T) {
+	// A random sub-image, so the table is relative to a non-zero origin.
+	r := rand.New(rand.NewSource(1))
+	m := image.NewGray(image.Rect(0, 0, 23, 17))
+	r.Read(m.Pix)
+	src := m.SubImage(image.Rect(3, 2, 20, 15)).(*image.Gray)
+	table := IntegralImage(src)
+
+	for i := 0; i < 200; i++ {
+		x0, x1 := r.Intn(18), r.Intn(18)
+		y0, y1 := r.Intn(14), r.Intn(14)
+		rect := image.Rect(x0, y0, x1, y1)
+		var want int64
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			for x := rect.Min.X; x < rect.Max.X; x++ {
+				want += int64(src.GrayAt(x+3, y+2).Y)
+			}
+		}
+		if got := IntegralSum(table, rect); got != want {
+			t.Errorf("%v: got %d want %d", rect, got, want)
+		}
+	}
+
+	// Rectangles are clipped to the image.
+	if got, want := IntegralSum(table, image.Rect(-5, -5, 100, 100)), IntegralSum(table, image.Rect(0, 0, 17, 13)); got != want {
+		t.Errorf("clipped sum: got %d want %d", got, want)
+	}
+}
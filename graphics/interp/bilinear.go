@@ -52,10 +52,10 @@ func bilinearGeneral(src image.Image, x, y float64) color.Color {
 	fa += float64(a) * p.frac11
 
 	var c color.RGBA64
-	c.R = uint16(fr + 0.5)
-	c.G = uint16(fg + 0.5)
-	c.B = uint16(fb + 0.5)
-	c.A = uint16(fa + 0.5)
+	c.R = clamp16(fr)
+	c.G = clamp16(fg)
+	c.B = clamp16(fb)
+	c.A = clamp16(fa)
 	return c
 }
 
@@ -91,10 +91,10 @@ func (bilinear) RGBA(src *image.RGBA, x, y float64) color.RGBA {
 	fa += float64(src.Pix[off11+3]) * p.frac11
 
 	var c color.RGBA
-	c.R = uint8(fr + 0.5)
-	c.G = uint8(fg + 0.5)
-	c.B = uint8(fb + 0.5)
-	c.A = uint8(fa + 0.5)
+	c.R = clamp8(fr)
+	c.G = clamp8(fg)
+	c.B = clamp8(fb)
+	c.A = clamp8(fa)
 	return c
 }
 
@@ -114,10 +114,20 @@ func (bilinear) Gray(src *image.Gray, x, y float64) color.Gray {
 	fc += float64(src.Pix[off11]) * p.frac11
 
 	var c color.Gray
-	c.Y = uint8(fc + 0.5)
+	c.Y = clamp8(fc)
 	return c
 }
 
+// BilinearWeights returns the source pixels and weights that Bilinear uses
+// to interpolate (x, y) within the bounds b. The interpolated value is
+//	w00*At(low.X, low.Y) + w01*At(high.X, low.Y) +
+//	w10*At(low.X, high.Y) + w11*At(high.X, high.Y)
+// It allows callers to precompute the interpolation of many points.
+func BilinearWeights(b image.Rectangle, x, y float64) (low, high image.Point, w00, w01, w10, w11 float64) {
+	p := findLinearSrc(b, x, y)
+	return p.low, p.high, p.frac00, p.frac01, p.frac10, p.frac11
+}
+
 type bilinearSrc struct {
 	// Top-left and bottom-right interpolation sources
 	low, high image.Point
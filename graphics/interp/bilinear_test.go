@@ -141,3 +141,53 @@ func TestBilinearSubImage(t *testing.T) {
 		}
 	}
 }
+
+func TestClamp(t *testing.T) {
+	tests8 := []struct {
+		f    float64
+		want uint8
+	}{
+		{-40.2, 0x00},
+		{0.4, 0x00},
+		{0.5, 0x01},
+		{254.6, 0xff},
+		{255.0000001, 0xff},
+		{300, 0xff},
+	}
+	for _, p := range tests8 {
+		if got := clamp8(p.f); got != p.want {
+			t.Errorf("clamp8(%v): got 0x%02x want 0x%02x", p.f, got, p.want)
+		}
+	}
+	if got := clamp16(70000); got != 0xffff {
+		t.Errorf("clamp16(70000): got 0x%04x want 0xffff", got)
+	}
+	if got := clamp16(-1); got != 0 {
+		t.Errorf("clamp16(-1): got 0x%04x want 0", got)
+	}
+}
+
+func TestBilinearEdgeNoWrap(t *testing.T) {
+	// Sampling across a hard black/white edge must rise monotonically. A
+	// near-white sum that wrapped around would show up as a sudden drop.
+	p := interpTest{
+		src: []uint8{
+			0x00, 0xff, 0xff,
+			0x00, 0xff, 0xff,
+		},
+		srcWidth: 3,
+	}
+	src := p.newSrc()
+	prev := uint8(0)
+	for i := 0; i <= 1000; i++ {
+		x := 0.5 + 2.5*float64(i)/1000
+		c := Bilinear.(RGBA).RGBA(src, x, 1.0)
+		if c.R < prev {
+			t.Fatalf("x=%v: got 0x%02x after 0x%02x", x, c.R, prev)
+		}
+		prev = c.R
+	}
+	if prev != 0xff {
+		t.Errorf("got 0x%02x at the white edge want 0xff", prev)
+	}
+}
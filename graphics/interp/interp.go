@@ -27,3 +27,28 @@ type Gray interface {
 	// Gray interpolates (x, y).
 	Gray(src *image.Gray, x, y float64) color.Gray
 }
+
+// clamp8 rounds an interpolated channel value to the nearest uint8,
+// clamping it to [0, 0xff]. Kernels with negative lobes can overshoot the
+// range, and a plain conversion would wrap around. Package graphics keeps
+// a copy, which must round the same way.
+func clamp8(f float64) uint8 {
+	if f <= 0 {
+		return 0
+	}
+	if f >= 0xff {
+		return 0xff
+	}
+	return uint8(f + 0.5)
+}
+
+// clamp16 is like clamp8, for 16-bit channels.
+func clamp16(f float64) uint16 {
+	if f <= 0 {
+		return 0
+	}
+	if f >= 0xffff {
+		return 0xffff
+	}
+	return uint16(f + 0.5)
+}
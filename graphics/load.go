@@ -0,0 +1,101 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+)
+
+// LoadOptions are the image loading parameters.
+// NoAutoOrient disables applying the EXIF orientation of JPEG images.
+// If Metadata is not nil, it is set to the metadata of JPEG images, for
+// passing on to SaveJPEGWithMetadata, and cleared for other formats.
+type LoadOptions struct {
+	NoAutoOrient bool
+	Metadata     *Metadata
+}
+
+// Metadata is the image metadata that Load can carry through to
+// SaveJPEGWithMetadata.
+type Metadata struct {
+	// ICC is the raw ICC color profile, or nil if there is none.
+	ICC []byte
+	// EXIF is the raw EXIF TIFF structure, or nil if there is none. If
+	// Load applied the orientation, the copy's orientation is normal.
+	EXIF []byte
+}
+
+// Load decodes the image file at path, in any format registered with the
+// image package, and returns it as an *image.RGBA with its top-left corner
+// at the origin. JPEG images are rotated and flipped according to their
+// EXIF orientation.
+func Load(path string) (*image.RGBA, error) {
+	return LoadWithOptions(path, nil)
+}
+
+// LoadWithOptions is like Load, with the given options.
+func LoadWithOptions(path string, opt *LoadOptions) (*image.RGBA, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	dst := crop(m, m.Bounds())
+	upright := format == "jpeg" && (opt == nil || !opt.NoAutoOrient)
+	if upright {
+		dst = orient(dst, exifOrientation(b))
+	}
+	if opt != nil && opt.Metadata != nil {
+		*opt.Metadata = Metadata{}
+		if format == "jpeg" {
+			*opt.Metadata = *jpegMetadata(b, upright)
+		}
+	}
+	return dst, nil
+}
+
+// orient returns src transformed from the EXIF orientation o to the
+// normal, upright orientation.
+func orient(src *image.RGBA, o int) *image.RGBA {
+	if o < 2 || o > 8 {
+		return src
+	}
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if o >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case 2: // Mirrored horizontally.
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°.
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically.
+				sx, sy = x, h-1-y
+			case 5: // Transposed.
+				sx, sy = y, x
+			case 6: // Rotated 90° counter-clockwise; rotate it clockwise.
+				sx, sy = y, h-1-x
+			case 7: // Transversed.
+				sx, sy = w-1-y, h-1-x
+			case 8: // Rotated 90° clockwise; rotate it counter-clockwise.
+				sx, sy = w-1-y, x
+			}
+			so := sy*src.Stride + sx*4
+			do := y*dst.Stride + x*4
+			copy(dst.Pix[do:do+4], src.Pix[so:so+4])
+		}
+	}
+	return dst
+}
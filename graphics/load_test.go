@@ -0,0 +1,168 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	_ "image/png"
+)
+
+// exifJPEG returns the JPEG encoding of m, with an EXIF segment holding the
+// orientation o.
+func exifJPEG(t *testing.T, m image.Image, o int) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, m, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // Header, with IFD0 at offset 8.
+		0, 1, // One entry.
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(o), 0, 0, // Orientation.
+		0, 0, 0, 0, // No next IFD.
+	}
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+	n := len(seg) + 2
+	app1 := append([]byte{0xff, 0xe1, byte(n >> 8), byte(n)}, seg...)
+	out := append([]byte{}, b[:2]...)
+	out = append(out, app1...)
+	return append(out, b[2:]...)
+}
+
+func TestLoadPNG(t *testing.T) {
+	m, err := Load("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), image.Rect(0, 0, 400, 600); !got.Eq(want) {
+		t.Errorf("got bounds %v want %v", got, want)
+	}
+}
+
+func TestLoadJPEGOrientation(t *testing.T) {
+	// A 16x8 image, white on the left half and black on the right.
+	src := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetRGBA(x, y, color.RGBA{0xff, 0xff, 0xff, 0xff})
+		}
+		for x := 8; x < 16; x++ {
+			src.SetRGBA(x, y, color.RGBA{0, 0, 0, 0xff})
+		}
+	}
+	dir := t.TempDir()
+
+	// Orientation 6 is corrected by rotating clockwise, so the white half
+	// ends up on top.
+	path := filepath.Join(dir, "rotated.jpg")
+	if err := ioutil.WriteFile(path, exifJPEG(t, src, 6), 0666); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), image.Rect(0, 0, 8, 16); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	if top, bottom := m.RGBAAt(4, 2).R, m.RGBAAt(4, 13).R; top < 0xf0 || bottom > 0x10 {
+		t.Errorf("got top 0x%02x bottom 0x%02x, want white over black", top, bottom)
+	}
+
+	m, err = LoadWithOptions(path, &LoadOptions{NoAutoOrient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), image.Rect(0, 0, 16, 8); !got.Eq(want) {
+		t.Errorf("no auto-orient: got bounds %v want %v", got, want)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with distinct pixels, and its upright form for each
+	// orientation, as indices into the source in row major order.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.Pix[i*4] = uint8(i)
+	}
+	tests := []struct {
+		o    int
+		w    int
+		want []uint8
+	}{
+		{1, 3, []uint8{0, 1, 2, 3, 4, 5}},
+		{2, 3, []uint8{2, 1, 0, 5, 4, 3}},
+		{3, 3, []uint8{5, 4, 3, 2, 1, 0}},
+		{4, 3, []uint8{3, 4, 5, 0, 1, 2}},
+		{5, 2, []uint8{0, 3, 1, 4, 2, 5}},
+		{6, 2, []uint8{3, 0, 4, 1, 5, 2}},
+		{7, 2, []uint8{5, 2, 4, 1, 3, 0}},
+		{8, 2, []uint8{2, 5, 1, 4, 0, 3}},
+	}
+	for _, tt := range tests {
+		m := orient(src, tt.o)
+		if m.Rect.Dx() != tt.w {
+			t.Errorf("o=%d: got width %d want %d", tt.o, m.Rect.Dx(), tt.w)
+			continue
+		}
+		got := make([]uint8, 6)
+		for i := range got {
+			got[i] = m.Pix[i*4]
+		}
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("o=%d: got %v want %v", tt.o, got, tt.want)
+		}
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	// A profile long enough to need two APP2 segments.
+	icc := make([]byte, maxICCChunk+100)
+	for i := range icc {
+		icc[i] = byte(i * 7)
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.jpg")
+	b := jpegWithMetadata(exifJPEG(t, NewNoise(16, 8, 1), 6), &Metadata{ICC: icc})
+	if err := ioutil.WriteFile(in, b, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	var md Metadata
+	m, err := LoadWithOptions(in, &LoadOptions{Metadata: &md})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(md.ICC, icc) {
+		t.Fatalf("loaded ICC profile of %d bytes, want %d", len(md.ICC), len(icc))
+	}
+	out := filepath.Join(dir, "out.jpg")
+	if err := SaveJPEGWithMetadata(m, out, 90, &md); err != nil {
+		t.Fatal(err)
+	}
+
+	// The saved image is upright, and its orientation says so.
+	var md2 Metadata
+	m2, err := LoadWithOptions(out, &LoadOptions{NoAutoOrient: true, Metadata: &md2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m2.Bounds(), image.Rect(0, 0, 8, 16); !got.Eq(want) {
+		t.Errorf("got bounds %v want %v", got, want)
+	}
+	if !bytes.Equal(md2.ICC, icc) {
+		t.Errorf("saved ICC profile of %d bytes, want %d", len(md2.ICC), len(icc))
+	}
+	if o := tiffOrientation(md2.EXIF); o != 1 {
+		t.Errorf("saved orientation %d, want 1", o)
+	}
+}
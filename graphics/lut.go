@@ -0,0 +1,57 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// ApplyColorLUT replaces each pixel of src with the entry of lut indexed by
+// its luminance, and draws the result onto dst. This colorizes a grayscale
+// image, such as with a false-color palette for a thermal image, or grades
+// a color one. The luminance is that of the non-premultiplied color,
+// rounded, and the entry is scaled by the alpha of the pixel, so
+// transparency is kept.
+func ApplyColorLUT(dst draw.Image, src image.Image, lut [256]color.RGBA) error {
+	return mapPixels(dst, src, func(_, _ int, p color.RGBA) color.RGBA {
+		a := uint32(p.A)
+		if a == 0 {
+			return color.RGBA{}
+		}
+		l := luma(p.R, p.G, p.B)
+		if a != 0xff {
+			l = l * 0xff / float64(a)
+		}
+		c := lut[clamp8(l)]
+		if a == 0xff {
+			return c
+		}
+		return color.RGBA{
+			uint8((uint32(c.R)*a + 0x7f) / 0xff),
+			uint8((uint32(c.G)*a + 0x7f) / 0xff),
+			uint8((uint32(c.B)*a + 0x7f) / 0xff),
+			uint8((uint32(c.A)*a + 0x7f) / 0xff),
+		}
+	})
+}
+
+// ApplyChannelLUTs maps the red, green and blue channels of each pixel of
+// src independently through r, g and b, and draws the result onto dst. The
+// tables map non-premultiplied values. Alpha is unchanged.
+func ApplyChannelLUTs(dst draw.Image, src image.Image, r, g, b *[256]uint8) error {
+	if r == nil || g == nil || b == nil {
+		return errors.New("graphics: nil lookup table")
+	}
+	var curve [3][256]float64
+	for i := 0; i < 256; i++ {
+		curve[0][i] = float64(r[i])
+		curve[1][i] = float64(g[i])
+		curve[2][i] = float64(b[i])
+	}
+	return applyTone(dst, src, &curve, false)
+}
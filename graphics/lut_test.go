@@ -0,0 +1,81 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestApplyColorLUT(t *testing.T) {
+	src := NewGradient(256, 4, color.Black, color.White)
+	var identity, reverse [256]color.RGBA
+	for i := range identity {
+		identity[i] = color.RGBA{uint8(i), uint8(i), uint8(i), 0xff}
+		reverse[i] = color.RGBA{uint8(255 - i), uint8(255 - i), uint8(255 - i), 0xff}
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	if err := ApplyColorLUT(dst, src, identity); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Errorf("identity: %v", err)
+	}
+
+	if err := ApplyColorLUT(dst, src, reverse); err != nil {
+		t.Fatal(err)
+	}
+	want := NewGradient(256, 4, color.White, color.Black)
+	if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+		t.Errorf("reverse: %v", err)
+	}
+
+	// The entry is chosen by the unpremultiplied luminance and scaled by
+	// the alpha of the pixel.
+	half := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	half.SetRGBA(0, 0, color.RGBA{0x30, 0x30, 0x30, 0x80})
+	var lut [256]color.RGBA
+	lut[0x60] = color.RGBA{0xff, 0x00, 0x00, 0xff}
+	if err := ApplyColorLUT(half, half, lut); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := half.RGBAAt(0, 0), (color.RGBA{0x80, 0x00, 0x00, 0x80}); got != want {
+		t.Errorf("translucent: got %v want %v", got, want)
+	}
+}
+
+func TestApplyChannelLUTs(t *testing.T) {
+	src := NewNoise(16, 16, 1)
+	var identity, reverse [256]uint8
+	for i := range identity {
+		identity[i], reverse[i] = uint8(i), uint8(255-i)
+	}
+
+	dst := image.NewRGBA(src.Bounds())
+	if err := ApplyChannelLUTs(dst, src, &identity, &identity, &identity); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Errorf("identity: %v", err)
+	}
+
+	// Reverse only the red channel.
+	if err := ApplyChannelLUTs(dst, src, &reverse, &identity, &identity); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(src.Pix); i += 4 {
+		p, q := src.Pix[i:i+4], dst.Pix[i:i+4]
+		if q[0] != 255-p[0] || q[1] != p[1] || q[2] != p[2] || q[3] != p[3] {
+			t.Fatalf("pixel %d: got %v want red of %v reversed", i/4, q, p)
+		}
+	}
+
+	if err := ApplyChannelLUTs(dst, src, nil, &identity, &identity); err == nil {
+		t.Error("nil table: got no error")
+	}
+}
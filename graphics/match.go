@@ -0,0 +1,117 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"math"
+)
+
+// MatchTemplate finds the position of template within src by normalized
+// cross-correlation of their luma. It returns the top-left corner, in src's
+// co-ordinates, of the best match, and its score from -1 to 1, where 1 is a
+// perfect match up to brightness and contrast. Candidates are compared
+// after subtracting their mean and dividing by their deviation, so a
+// uniformly brightened or darkened copy of template still scores 1.
+//
+// The cost is proportional to the area of src times the area of template.
+// If template is larger than src, or either is empty, MatchTemplate returns
+// src's top-left corner and a score of 0.
+func MatchTemplate(src, template image.Image) (image.Point, float64) {
+	sb, tb := src.Bounds(), template.Bounds()
+	sw, sh, tw, th := sb.Dx(), sb.Dy(), tb.Dx(), tb.Dy()
+	if tw == 0 || th == 0 || tw > sw || th > sh {
+		return sb.Min, 0
+	}
+	slum, tlum := lumaPlane(src), lumaPlane(template)
+
+	// Center the template once.
+	n := float64(tw * th)
+	var tmean float64
+	for _, v := range tlum {
+		tmean += v
+	}
+	tmean /= n
+	var tnorm float64
+	for i, v := range tlum {
+		tlum[i] = v - tmean
+		tnorm += tlum[i] * tlum[i]
+	}
+
+	// The window sums come from summed-area tables, so only the
+	// correlation itself costs the area of template per position.
+	sum, sum2 := summedArea(slum, sw, sh, false), summedArea(slum, sw, sh, true)
+	windowSum := func(t []float64, x, y int) float64 {
+		x1, y1 := x+tw, y+th
+		return t[y1*(sw+1)+x1] - t[y*(sw+1)+x1] - t[y1*(sw+1)+x] + t[y*(sw+1)+x]
+	}
+
+	rows := sh - th + 1
+	bestPt := make([]image.Point, rows)
+	bestScore := make([]float64, rows)
+	parallelRows(0, rows, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			bestScore[y] = math.Inf(-1)
+			for x := 0; x+tw <= sw; x++ {
+				s, s2 := windowSum(sum, x, y), windowSum(sum2, x, y)
+				snorm := s2 - s*s/n
+				var cross float64
+				for ty := 0; ty < th; ty++ {
+					srow := slum[(y+ty)*sw+x:]
+					trow := tlum[ty*tw:]
+					for tx := 0; tx < tw; tx++ {
+						cross += srow[tx] * trow[tx]
+					}
+				}
+				// cross is already the centered correlation, since the
+				// template sums to zero.
+				score := 0.0
+				if d := math.Sqrt(snorm * tnorm); d > 1e-9 {
+					score = cross / d
+				}
+				if score > bestScore[y] {
+					bestScore[y], bestPt[y] = score, image.Pt(x, y)
+				}
+			}
+		}
+	})
+
+	best := 0
+	for y := range bestScore {
+		if bestScore[y] > bestScore[best] {
+			best = y
+		}
+	}
+	return sb.Min.Add(bestPt[best]), bestScore[best]
+}
+
+// lumaPlane returns the luma of m in row major order.
+func lumaPlane(m image.Image) []float64 {
+	s := crop(m, m.Bounds())
+	lum := make([]float64, len(s.Pix)/4)
+	for i := range lum {
+		p := s.Pix[i*4:]
+		lum[i] = luma(p[0], p[1], p[2])
+	}
+	return lum
+}
+
+// summedArea returns the (w+1)*(h+1) summed-area table of the w*h plane p,
+// or of its squares if squared is set.
+func summedArea(p []float64, w, h int, squared bool) []float64 {
+	t := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var row float64
+		for x := 0; x < w; x++ {
+			v := p[y*w+x]
+			if squared {
+				v *= v
+			}
+			row += v
+			t[(y+1)*(w+1)+x+1] = t[y*(w+1)+x+1] + row
+		}
+	}
+	return t
+}
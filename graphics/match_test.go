@@ -0,0 +1,43 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestMatchTemplate(t *testing.T) {
+	src := NewNoise(60, 40, 1)
+	src.Rect = src.Rect.Add(image.Pt(100, 200))
+	patch := crop(NewNoise(9, 7, 2), image.Rect(0, 0, 9, 7))
+	at := image.Pt(137, 222)
+	draw.Draw(src, patch.Bounds().Add(at), patch, image.Point{}, draw.Src)
+
+	// The patch is found exactly, and a darkened copy of it matches too.
+	dark := image.NewRGBA(patch.Bounds())
+	for i, v := range patch.Pix {
+		if i%4 == 3 {
+			dark.Pix[i] = v
+		} else {
+			dark.Pix[i] = v / 2
+		}
+	}
+	for _, tmpl := range []*image.RGBA{patch, dark} {
+		p, score := MatchTemplate(src, tmpl)
+		if p != at {
+			t.Errorf("got %v want %v", p, at)
+		}
+		if math.Abs(score-1) > 0.01 {
+			t.Errorf("score %v, want 1", score)
+		}
+	}
+
+	if p, score := MatchTemplate(patch, src); p != (image.Point{}) || score != 0 {
+		t.Errorf("oversized template: got %v %v", p, score)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+)
+
+// OilPaint produces a painterly version of src, drawn onto dst.
+//
+// For each pixel, the neighbouring pixels within radius are sorted into
+// levels bins by intensity. The output pixel is the average color of the
+// most populated bin. The cost is proportional to the number of pixels
+// times (2*radius+1)^2, so large radii are slow; rows are processed
+// concurrently.
+func OilPaint(dst draw.Image, src image.Image, radius, levels int) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if radius < 0 {
+		return errors.New("graphics: negative radius")
+	}
+	if levels < 1 {
+		return errors.New("graphics: levels must be positive")
+	}
+
+	s := toRGBA(src)
+	sb := s.Bounds()
+	b := dst.Bounds().Intersect(sb)
+	if b.Empty() {
+		return nil
+	}
+
+	d, ok := dst.(*image.RGBA)
+	if !ok {
+		d = image.NewRGBA(b)
+	}
+
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		count := make([]int, levels)
+		sum := make([][4]int, levels)
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				for i := range count {
+					count[i] = 0
+					sum[i] = [4]int{}
+				}
+				r := image.Rect(x-radius, y-radius, x+radius+1, y+radius+1).Intersect(sb)
+				for sy := r.Min.Y; sy < r.Max.Y; sy++ {
+					off := (sy-sb.Min.Y)*s.Stride + (r.Min.X-sb.Min.X)*4
+					for sx := r.Min.X; sx < r.Max.X; sx++ {
+						p := s.Pix[off : off+4]
+						l := (int(p[0]) + int(p[1]) + int(p[2])) * levels / (3 * 256)
+						count[l]++
+						sum[l][0] += int(p[0])
+						sum[l][1] += int(p[1])
+						sum[l][2] += int(p[2])
+						sum[l][3] += int(p[3])
+						off += 4
+					}
+				}
+
+				// Ties go to the darker bin, keeping the result deterministic.
+				best := 0
+				for i, c := range count {
+					if c > count[best] {
+						best = i
+					}
+				}
+				n := count[best]
+				off := (y-d.Rect.Min.Y)*d.Stride + (x-d.Rect.Min.X)*4
+				d.Pix[off+0] = uint8((sum[best][0] + n/2) / n)
+				d.Pix[off+1] = uint8((sum[best][1] + n/2) / n)
+				d.Pix[off+2] = uint8((sum[best][2] + n/2) / n)
+				d.Pix[off+3] = uint8((sum[best][3] + n/2) / n)
+			}
+		}
+	})
+
+	if !ok {
+		draw.Draw(dst, b, d, b.Min, draw.Src)
+	}
+	return nil
+}
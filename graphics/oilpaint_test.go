@@ -0,0 +1,60 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+
+	_ "image/png"
+)
+
+func TestOilPaintFlat(t *testing.T) {
+	b := image.Rect(0, 0, 8, 8)
+	src := image.NewRGBA(b)
+	c := color.RGBA{0x40, 0x80, 0xc0, 0xff}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	dst := image.NewRGBA(b)
+	if err := OilPaint(dst, src, 2, 8); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOilPaintDeterministic(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst0 := image.NewRGBA(src.Bounds())
+	if err := OilPaint(dst0, src, 2, 16); err != nil {
+		t.Fatal(err)
+	}
+	dst1 := image.NewRGBA(src.Bounds())
+	if err := OilPaint(dst1, src, 2, 16); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst0.Pix, dst1.Pix) {
+		t.Error("repeated OilPaint produced different output")
+	}
+}
+
+func TestOilPaintEmpty(t *testing.T) {
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	if err := OilPaint(empty, empty, 3, 8); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+)
+
+// PadMode controls how Pad fills the border around an image.
+type PadMode int
+
+const (
+	// PadZero fills the border with transparent black.
+	PadZero PadMode = iota
+	// PadEdge repeats the nearest edge pixel.
+	PadEdge
+	// PadReflect mirrors the image about its edges, repeating the edge
+	// pixel, so a row abc is padded as cba|abc|cba.
+	PadReflect
+	// PadWrap tiles the image, so a row abc is padded as abc|abc|abc.
+	PadWrap
+)
+
+// Pad returns a copy of src surrounded by a border of the given widths,
+// filled according to mode. The result's bounds start at the origin, so
+// the pixels of src are at (left, top). Negative widths are treated as
+// zero.
+func Pad(src image.Image, top, right, bottom, left int, mode PadMode) *image.RGBA {
+	top, right, bottom, left = max0(top), max0(right), max0(bottom), max0(left)
+	sb := src.Bounds()
+	s := toRGBA(src)
+	w, h := sb.Dx(), sb.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w+left+right, h+top+bottom))
+	if w == 0 || h == 0 {
+		return dst
+	}
+
+	db := dst.Bounds()
+	for y := 0; y < db.Max.Y; y++ {
+		sy, ok := padIndex(y-top, h, mode)
+		if !ok {
+			continue
+		}
+		srow := s.Pix[(sy+sb.Min.Y-s.Rect.Min.Y)*s.Stride:]
+		drow := dst.Pix[y*dst.Stride:]
+		for x := 0; x < db.Max.X; x++ {
+			sx, ok := padIndex(x-left, w, mode)
+			if !ok {
+				continue
+			}
+			so := (sx + sb.Min.X - s.Rect.Min.X) * 4
+			copy(drow[x*4:x*4+4], srow[so:so+4])
+		}
+	}
+	return dst
+}
+
+// padIndex maps the index i, which may lie outside [0, n), to the index
+// of the sample that mode pads it with. ok is false if the sample is zero.
+func padIndex(i, n int, mode PadMode) (j int, ok bool) {
+	if i >= 0 && i < n {
+		return i, true
+	}
+	switch mode {
+	case PadEdge:
+		if i < 0 {
+			return 0, true
+		}
+		return n - 1, true
+	case PadReflect:
+		i %= 2 * n
+		if i < 0 {
+			i += 2 * n
+		}
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i, true
+	case PadWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+func max0(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
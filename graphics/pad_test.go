@@ -0,0 +1,65 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPad(t *testing.T) {
+	// A 3x2 image whose red channel numbers the pixels 1 to 6, offset from
+	// the origin.
+	src := image.NewRGBA(image.Rect(10, 20, 13, 22))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			src.SetRGBA(10+x, 20+y, color.RGBA{uint8(1 + y*3 + x), 0, 0, 0xff})
+		}
+	}
+
+	// Each test pads by 4 on the left, 2 on the right and 3 on top, and
+	// checks the red values of the first padded row and the rows of src.
+	tests := []struct {
+		mode PadMode
+		want [][]uint8
+	}{
+		{PadZero, [][]uint8{
+			{0, 0, 0, 0, 0, 0, 0, 0, 0},
+			{0, 0, 0, 0, 1, 2, 3, 0, 0},
+			{0, 0, 0, 0, 4, 5, 6, 0, 0},
+		}},
+		{PadEdge, [][]uint8{
+			{1, 1, 1, 1, 1, 2, 3, 3, 3},
+			{1, 1, 1, 1, 1, 2, 3, 3, 3},
+			{4, 4, 4, 4, 4, 5, 6, 6, 6},
+		}},
+		{PadReflect, [][]uint8{
+			// Row -3 reflects to row 1 with a period of 4 rows.
+			{6, 6, 5, 4, 4, 5, 6, 6, 5},
+			{3, 3, 2, 1, 1, 2, 3, 3, 2},
+			{6, 6, 5, 4, 4, 5, 6, 6, 5},
+		}},
+		{PadWrap, [][]uint8{
+			{6, 4, 5, 6, 4, 5, 6, 4, 5},
+			{3, 1, 2, 3, 1, 2, 3, 1, 2},
+			{6, 4, 5, 6, 4, 5, 6, 4, 5},
+		}},
+	}
+	for _, tt := range tests {
+		dst := Pad(src, 3, 2, 1, 4, tt.mode)
+		if got, want := dst.Bounds(), image.Rect(0, 0, 9, 6); got != want {
+			t.Errorf("mode %d: bounds %v want %v", tt.mode, got, want)
+			continue
+		}
+		for i, y := range []int{0, 3, 4} {
+			for x, want := range tt.want[i] {
+				if got := dst.RGBAAt(x, y).R; got != want {
+					t.Errorf("mode %d: (%d, %d) got %d want %d", tt.mode, x, y, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"image"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetMaxWorkers(t *testing.T) {
+	defer SetMaxWorkers(0)
+
+	for _, n := range []int{1, 2, 3} {
+		SetMaxWorkers(n)
+		var mu sync.Mutex
+		active, peak, calls := 0, 0, 0
+		parallelRows(0, 100, func(y0, y1 int) {
+			mu.Lock()
+			active++
+			calls++
+			if active > peak {
+				peak = active
+			}
+			mu.Unlock()
+
+			// Linger so that any excess workers would overlap.
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+		})
+		if calls != n {
+			t.Errorf("n=%d: got %d bands", n, calls)
+		}
+		if peak > n {
+			t.Errorf("n=%d: got %d concurrent workers", n, peak)
+		}
+	}
+}
+
+func TestParallelRowsCoverage(t *testing.T) {
+	defer SetMaxWorkers(0)
+	SetMaxWorkers(7)
+
+	var mu sync.Mutex
+	seen := make([]int, 50)
+	parallelRows(10, 60, func(y0, y1 int) {
+		mu.Lock()
+		defer mu.Unlock()
+		for y := y0; y < y1; y++ {
+			seen[y-10]++
+		}
+	})
+	for i, n := range seen {
+		if n != 1 {
+			t.Errorf("row %d visited %d times", i+10, n)
+		}
+	}
+}
+
+func TestWorkerCountOutput(t *testing.T) {
+	defer SetMaxWorkers(0)
+	src := NewNoise(40, 30, 5)
+
+	var want []byte
+	for _, n := range []int{1, 2, 5} {
+		SetMaxWorkers(n)
+		dst := image.NewRGBA(src.Bounds())
+		if err := OilPaint(dst, src, 2, 8); err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = dst.Pix
+		} else if !bytes.Equal(dst.Pix, want) {
+			t.Errorf("n=%d: output differs from single worker", n)
+		}
+	}
+}
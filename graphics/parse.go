@@ -0,0 +1,183 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseAffine parses a CSS transform property or SVG transform attribute
+// value, such as the output of CSSMatrix, into an Affine. It accepts a
+// list of the functions matrix(a, b, c, d, e, f), translate(tx[, ty]),
+// scale(sx[, sy]) and rotate(angle[, cx, cy]), whose arguments may be
+// separated by commas or spaces. Lengths may have the unit px, and angles
+// the units deg, rad or turn; angles without a unit are in degrees, as in
+// SVG. As with CSSMatrix, the CSS and SVG transforms map the element onto
+// the page, and the result is their inverse, mapping dst to src.
+func ParseAffine(s string) (Affine, error) {
+	fwd := I
+	rest := strings.TrimSpace(s)
+	if rest == "" || rest == "none" {
+		return I, nil
+	}
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		close := strings.IndexByte(rest, ')')
+		if open < 0 || close < open {
+			return Affine{}, errors.New("graphics: malformed transform " + strconv.Quote(s))
+		}
+		name := strings.TrimSpace(rest[:open])
+		args, err := parseTransformArgs(name, rest[open+1:close])
+		if err != nil {
+			return Affine{}, err
+		}
+		m, err := transformFunc(name, args)
+		if err != nil {
+			return Affine{}, err
+		}
+		fwd = fwd.Mul(m)
+		rest = strings.TrimLeft(rest[close+1:], ", \t\n\r")
+	}
+	if err := fwd.Valid(); err != nil {
+		return Affine{}, err
+	}
+	a, _ := fwd.invert()
+	return a, nil
+}
+
+// transformArg is an argument of a transform function: a number and its
+// unit, which may be empty.
+type transformArg struct {
+	v    float64
+	unit string
+}
+
+func parseTransformArgs(name, s string) ([]transformArg, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	args := make([]transformArg, len(fields))
+	for i, f := range fields {
+		n := strings.IndexFunc(f, func(r rune) bool {
+			return unicode.IsLetter(r) && r != 'e' && r != 'E'
+		})
+		if n < 0 {
+			n = len(f)
+		}
+		v, err := strconv.ParseFloat(f[:n], 64)
+		if err != nil {
+			return nil, errors.New("graphics: bad " + name + " argument " + strconv.Quote(f))
+		}
+		args[i] = transformArg{v, f[n:]}
+	}
+	return args, nil
+}
+
+// transformFunc returns the forward matrix, mapping the element onto the
+// page, of the transform function name with the arguments args.
+func transformFunc(name string, args []transformArg) (Affine, error) {
+	bad := errors.New("graphics: bad arguments to " + name)
+	// length and angle return the argument i in pixels or radians.
+	length := func(i int) (float64, error) {
+		if u := args[i].unit; u != "" && u != "px" {
+			return 0, bad
+		}
+		return args[i].v, nil
+	}
+	angle := func(i int) (float64, error) {
+		switch args[i].unit {
+		case "", "deg":
+			return args[i].v * math.Pi / 180, nil
+		case "rad":
+			return args[i].v, nil
+		case "turn":
+			return args[i].v * 2 * math.Pi, nil
+		}
+		return 0, bad
+	}
+	v := make([]float64, len(args))
+	switch name {
+	case "matrix":
+		if len(args) != 6 {
+			return Affine{}, bad
+		}
+		for i, arg := range args {
+			if arg.unit != "" {
+				return Affine{}, bad
+			}
+			v[i] = arg.v
+		}
+		return Affine{
+			v[0], v[2], v[4],
+			v[1], v[3], v[5],
+			0, 0, 1,
+		}, nil
+	case "translate":
+		if len(args) != 1 && len(args) != 2 {
+			return Affine{}, bad
+		}
+		for i := range args {
+			var err error
+			if v[i], err = length(i); err != nil {
+				return Affine{}, err
+			}
+		}
+		v = append(v, 0)
+		return Affine{
+			1, 0, v[0],
+			0, 1, v[1],
+			0, 0, 1,
+		}, nil
+	case "scale":
+		if len(args) != 1 && len(args) != 2 {
+			return Affine{}, bad
+		}
+		for i, arg := range args {
+			if arg.unit != "" {
+				return Affine{}, bad
+			}
+			v[i] = arg.v
+		}
+		if len(args) == 1 {
+			v = append(v, v[0])
+		}
+		return Affine{
+			v[0], 0, 0,
+			0, v[1], 0,
+			0, 0, 1,
+		}, nil
+	case "rotate":
+		if len(args) != 1 && len(args) != 3 {
+			return Affine{}, bad
+		}
+		theta, err := angle(0)
+		if err != nil {
+			return Affine{}, err
+		}
+		sin, cos := math.Sincos(theta)
+		r := Affine{
+			cos, -sin, 0,
+			sin, cos, 0,
+			0, 0, 1,
+		}
+		if len(args) == 1 {
+			return r, nil
+		}
+		// SVG's rotate(angle, cx, cy) rotates about (cx, cy).
+		for i := 1; i < 3; i++ {
+			if v[i], err = length(i); err != nil {
+				return Affine{}, err
+			}
+		}
+		return Affine{1, 0, v[1], 0, 1, v[2], 0, 0, 1}.
+			Mul(r).
+			Mul(Affine{1, 0, -v[1], 0, 1, -v[2], 0, 0, 1}), nil
+	}
+	return Affine{}, errors.New("graphics: unknown transform function " + strconv.Quote(name))
+}
@@ -0,0 +1,71 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseAffineRoundTrip(t *testing.T) {
+	for _, a := range []Affine{
+		I,
+		I.Scale(2, 3).Translate(10, 20),
+		I.Rotate(0.3).Shear(0.2, 0).Translate(-4.5, 7).Scale(0.25, 0.5),
+	} {
+		for _, s := range []string{a.CSSMatrix(), a.SVGTransform()} {
+			got, err := ParseAffine(s)
+			if err != nil {
+				t.Errorf("%q: %v", s, err)
+				continue
+			}
+			if !got.Equal(a, 1e-9) {
+				t.Errorf("%q: got %v want %v", s, got, a)
+			}
+		}
+	}
+}
+
+func TestParseAffine(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Affine
+	}{
+		{"none", I},
+		{" translate( 10px ,20px ) ", I.Translate(10, 20)},
+		{"translate(10)", I.Translate(10, 0)},
+		// The functions apply right to left: scale, then translate.
+		{"translate(10px, 20px) scale(2)", I.Scale(2, 2).Translate(10, 20)},
+		{"scale(2,3) rotate(90deg)", I.Rotate(math.Pi/2).Scale(2, 3)},
+		{"rotate(0.25turn)", I.Rotate(math.Pi / 2)},
+		{"rotate(3.14159265358979rad)", I.Rotate(math.Pi)},
+		{"rotate(90 10 20)", I.Rotate(math.Pi/2).Center(10, 20)},
+		{"matrix(1e0 0 0 1 5 -5)", I.Translate(5, -5)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAffine(tt.s)
+		if err != nil {
+			t.Errorf("%q: %v", tt.s, err)
+			continue
+		}
+		if !got.Equal(tt.want, 1e-9) {
+			t.Errorf("%q: got %v want %v", tt.s, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{
+		"skewX(10deg)",
+		"translate(10px",
+		"matrix(1, 2, 3)",
+		"scale(2px)",
+		"rotate(10px)",
+		"translate(ten)",
+		"scale(0)",
+	} {
+		if _, err := ParseAffine(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"math"
+	"math/rand"
+)
+
+// PerlinOptions are the fractal noise parameters.
+// Octaves is the number of noise layers summed together. Each octave
+// doubles the frequency of the previous one and scales its amplitude by
+// Persistence. If Octaves is zero, a single octave is used. If Persistence
+// is zero, it is set to 0.5.
+type PerlinOptions struct {
+	Octaves     int
+	Persistence float64
+}
+
+// Perlin returns a w by h image of tileable Perlin gradient noise. Scale is
+// the approximate size in pixels of a noise feature; it is adjusted so that
+// a whole number of features fit across the image and the image tiles
+// seamlessly. The same seed always produces the same image.
+func Perlin(w, h int, scale float64, seed int64, opt *PerlinOptions) *image.Gray {
+	octaves, persistence := 1, 0.5
+	if opt != nil {
+		if opt.Octaves > 0 {
+			octaves = opt.Octaves
+		}
+		if opt.Persistence != 0 {
+			persistence = opt.Persistence
+		}
+	}
+
+	m := image.NewGray(image.Rect(0, 0, w, h))
+	if w <= 0 || h <= 0 {
+		return m
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	px := int(math.Max(1, math.Floor(float64(w)/scale+0.5)))
+	py := int(math.Max(1, math.Floor(float64(h)/scale+0.5)))
+
+	r := rand.New(rand.NewSource(seed))
+	var perm [512]int
+	p := r.Perm(256)
+	for i := range perm {
+		perm[i] = p[i&0xff]
+	}
+	var grad [256][2]float64
+	for i := range grad {
+		s, c := math.Sincos(2 * math.Pi * r.Float64())
+		grad[i] = [2]float64{c, s}
+	}
+
+	noise := func(x, y float64, px, py, octave int) float64 {
+		x0, y0 := math.Floor(x), math.Floor(y)
+		fx, fy := x-x0, y-y0
+		ix, iy := int(x0), int(y0)
+		dot := func(i, j int, dx, dy float64) float64 {
+			gi, gj := (ix+i)%px, (iy+j)%py
+			g := grad[perm[perm[(gi+octave)&0xff]+gj&0xff]]
+			return g[0]*dx + g[1]*dy
+		}
+		u, v := fade(fx), fade(fy)
+		n0 := lerp(dot(0, 0, fx, fy), dot(1, 0, fx-1, fy), u)
+		n1 := lerp(dot(0, 1, fx, fy-1), dot(1, 1, fx-1, fy-1), u)
+		return lerp(n0, n1, v)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sum, amp, norm := 0.0, 1.0, 0.0
+			ox, oy := px, py
+			for o := 0; o < octaves; o++ {
+				nx := (float64(x) + 0.5) * float64(ox) / float64(w)
+				ny := (float64(y) + 0.5) * float64(oy) / float64(h)
+				sum += amp * noise(nx, ny, ox, oy, o)
+				norm += amp
+				amp *= persistence
+				ox, oy = ox*2, oy*2
+			}
+			// Two dimensional gradient noise lies within [-√½, √½].
+			v := (sum/norm*math.Sqrt2 + 1) / 2
+			m.Pix[y*m.Stride+x] = uint8(math.Max(0, math.Min(255, v*255+0.5)))
+		}
+	}
+	return m
+}
+
+// fade is Perlin's quintic smoothstep, 6t^5 - 15t^4 + 10t^3.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
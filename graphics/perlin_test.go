@@ -0,0 +1,59 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPerlinDeterministic(t *testing.T) {
+	opt := &PerlinOptions{Octaves: 3}
+	m0 := Perlin(64, 48, 16, 7, opt)
+	m1 := Perlin(64, 48, 16, 7, opt)
+	m2 := Perlin(64, 48, 16, 8, opt)
+	if !bytes.Equal(m0.Pix, m1.Pix) {
+		t.Error("same seed produced different noise")
+	}
+	if bytes.Equal(m0.Pix, m2.Pix) {
+		t.Error("different seeds produced identical noise")
+	}
+}
+
+func TestPerlinSmooth(t *testing.T) {
+	const w, h = 64, 64
+	m := Perlin(w, h, 32, 1, nil)
+	diff := func(a, b uint8) int {
+		if a > b {
+			return int(a - b)
+		}
+		return int(b - a)
+	}
+
+	min, max := uint8(0xff), uint8(0)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := m.GrayAt(x, y).Y
+			if c < min {
+				min = c
+			}
+			if c > max {
+				max = c
+			}
+			// Wrapping around the edge checks that the noise tiles.
+			right := m.GrayAt((x+1)%w, y).Y
+			below := m.GrayAt(x, (y+1)%h).Y
+			if d := diff(c, right); d > 16 {
+				t.Fatalf("(%d, %d): horizontal step %d too large", x, y, d)
+			}
+			if d := diff(c, below); d > 16 {
+				t.Fatalf("(%d, %d): vertical step %d too large", x, y, d)
+			}
+		}
+	}
+	if max-min < 32 {
+		t.Errorf("noise is nearly flat: range [%d, %d]", min, max)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// EachPixel replaces each pixel of img, in place, with the result of
+// calling fn with its co-ordinates and premultiplied color. Rows are
+// processed in parallel, so fn may be called concurrently and must not
+// depend on the order of the calls, nor read other pixels of img. The
+// per-pixel filters of this package, such as Gamma, Vibrance and
+// SnapAlpha, are built on it.
+func EachPixel(img *image.RGBA, fn func(x, y int, c color.RGBA) color.RGBA) {
+	b := img.Bounds()
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			off := (y-img.Rect.Min.Y)*img.Stride + (b.Min.X-img.Rect.Min.X)*4
+			for x := b.Min.X; x < b.Max.X; x, off = x+1, off+4 {
+				p := img.Pix[off : off+4 : off+4]
+				c := fn(x, y, color.RGBA{p[0], p[1], p[2], p[3]})
+				p[0], p[1], p[2], p[3] = c.R, c.G, c.B, c.A
+			}
+		}
+	})
+}
+
+// mapPixels draws onto dst the pixels of src, within the bounds they share,
+// each replaced by the result of fn as for EachPixel. dst and src may be
+// the same image.
+func mapPixels(dst draw.Image, src image.Image, fn func(x, y int, c color.RGBA) color.RGBA) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	buf := image.NewRGBA(b)
+	draw.Draw(buf, b, src, b.Min, draw.Src)
+	EachPixel(buf, fn)
+	draw.Draw(dst, b, buf, b.Min, draw.Src)
+	return nil
+}
+
+// unpremul returns the channel v of a color with the non-zero alpha a,
+// un-premultiplied.
+func unpremul(v uint8, a uint32) uint8 {
+	u := (uint32(v)*0xff + a/2) / a
+	if u > 0xff {
+		u = 0xff
+	}
+	return uint8(u)
+}
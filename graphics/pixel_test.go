@@ -0,0 +1,57 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestEachPixel(t *testing.T) {
+	// A view into a larger image, not at the origin, so that the stride
+	// differs from the width.
+	big := NewNoise(30, 20, 1)
+	r := image.Rect(5, 4, 17, 13)
+	m := big.SubImage(r).(*image.RGBA)
+	orig := crop(big, big.Bounds())
+
+	// The identity changes nothing, and visits each pixel once.
+	seen := make(chan image.Point, r.Dx()*r.Dy()+1)
+	EachPixel(m, func(x, y int, c color.RGBA) color.RGBA {
+		seen <- image.Pt(x, y)
+		return c
+	})
+	close(seen)
+	visited := map[image.Point]bool{}
+	for p := range seen {
+		if !p.In(r) || visited[p] {
+			t.Fatalf("visited %v twice or outside %v", p, r)
+		}
+		visited[p] = true
+	}
+	if len(visited) != r.Dx()*r.Dy() {
+		t.Errorf("visited %d pixels want %d", len(visited), r.Dx()*r.Dy())
+	}
+	if err := graphicstest.ImageWithinTolerance(big, orig, 0); err != nil {
+		t.Errorf("identity: %v", err)
+	}
+
+	// A constant fills the view, and only it.
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	EachPixel(m, func(_, _ int, _ color.RGBA) color.RGBA { return red })
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 30; x++ {
+			want := orig.RGBAAt(x, y)
+			if image.Pt(x, y).In(r) {
+				want = red
+			}
+			if got := big.RGBAAt(x, y); got != want {
+				t.Fatalf("constant: (%d, %d) got %v want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"errors"
+	"image"
+)
+
+// planSample is the precomputed bilinear interpolation of one dst pixel.
+// The source pixels are relative to the source bounds' Min.
+type planSample struct {
+	dst       int32
+	low, high [2]int32
+	w         [4]float64
+}
+
+// TransformPlan is an affine transform with its bilinear sample positions
+// and weights precomputed for fixed dst and src bounds. Applying a plan to
+// many images of the same size, such as the frames of a video, skips the
+// per-pixel geometry at the cost of memory proportional to dst's area.
+type TransformPlan struct {
+	dstBounds, srcBounds image.Rectangle
+	samples              []planSample
+}
+
+// Precompute returns a plan for transforming images with the bounds
+// srcBounds by a into images with the bounds dstBounds, using bilinear
+// interpolation.
+func Precompute(a Affine, dstBounds, srcBounds image.Rectangle) (*TransformPlan, error) {
+	if err := a.Valid(); err != nil {
+		return nil, err
+	}
+	p := &TransformPlan{dstBounds: dstBounds, srcBounds: srcBounds}
+	smin := srcBounds.Min
+	for y := dstBounds.Min.Y; y < dstBounds.Max.Y; y++ {
+		for x := dstBounds.Min.X; x < dstBounds.Max.X; x++ {
+			sx, sy := a.pt(x, y)
+			if !inBounds(srcBounds, sx, sy) {
+				continue
+			}
+			low, high, w00, w01, w10, w11 := interp.BilinearWeights(srcBounds, sx, sy)
+			p.samples = append(p.samples, planSample{
+				dst:  int32((y-dstBounds.Min.Y)*dstBounds.Dx() + x - dstBounds.Min.X),
+				low:  [2]int32{int32(low.X - smin.X), int32(low.Y - smin.Y)},
+				high: [2]int32{int32(high.X - smin.X), int32(high.Y - smin.Y)},
+				w:    [4]float64{w00, w01, w10, w11},
+			})
+		}
+	}
+	return p, nil
+}
+
+// Transform applies the plan to src and produces dst. The bounds of dst
+// and src must be those the plan was computed for. The output is identical
+// to that of the equivalent Affine.Transform with interp.Bilinear.
+func (p *TransformPlan) Transform(dst, src *image.RGBA) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if !dst.Bounds().Eq(p.dstBounds) || !src.Bounds().Eq(p.srcBounds) {
+		return errors.New("graphics: bounds do not match the transform plan")
+	}
+
+	w := p.dstBounds.Dx()
+	parallelRows(0, len(p.samples), func(i0, i1 int) {
+		for _, s := range p.samples[i0:i1] {
+			off00 := int(s.low[1])*src.Stride + int(s.low[0])*4
+			off01 := int(s.low[1])*src.Stride + int(s.high[0])*4
+			off10 := int(s.high[1])*src.Stride + int(s.low[0])*4
+			off11 := int(s.high[1])*src.Stride + int(s.high[0])*4
+			d := int(s.dst)
+			doff := (d/w)*dst.Stride + (d%w)*4
+			for c := 0; c < 4; c++ {
+				var f float64
+				f += float64(src.Pix[off00+c]) * s.w[0]
+				f += float64(src.Pix[off01+c]) * s.w[1]
+				f += float64(src.Pix[off10+c]) * s.w[2]
+				f += float64(src.Pix[off11+c]) * s.w[3]
+				dst.Pix[doff+c] = clamp8(f)
+			}
+		}
+	})
+	return nil
+}
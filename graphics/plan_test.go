@@ -0,0 +1,70 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"testing"
+)
+
+func TestTransformPlan(t *testing.T) {
+	srcb := image.Rect(0, 0, 60, 40)
+	dstb := image.Rect(0, 0, 50, 50)
+	a := I.Rotate(0.4).Scale(0.9, 1.3).CenterFit(dstb, srcb)
+
+	p, err := Precompute(a, dstb, srcb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seed := int64(0); seed < 3; seed++ {
+		src := NewNoise(60, 40, seed)
+		want := image.NewRGBA(dstb)
+		if err := a.Transform(want, src, interp.Bilinear); err != nil {
+			t.Fatal(err)
+		}
+		got := image.NewRGBA(dstb)
+		if err := p.Transform(got, src); err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+			t.Errorf("seed %d: %v", seed, err)
+		}
+	}
+
+	if err := p.Transform(image.NewRGBA(srcb), NewNoise(60, 40, 0)); err == nil {
+		t.Error("expected error for mismatched bounds")
+	}
+}
+
+func benchPlanSetup() (Affine, image.Rectangle, []*image.RGBA) {
+	b := image.Rect(0, 0, 200, 200)
+	a := I.Rotate(0.3).CenterFit(b, b)
+	frames := make([]*image.RGBA, 4)
+	for i := range frames {
+		frames[i] = NewNoise(200, 200, int64(i))
+	}
+	return a, b, frames
+}
+
+func BenchmarkTransformBatch(b *testing.B) {
+	a, r, frames := benchPlanSetup()
+	dst := image.NewRGBA(r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Transform(dst, frames[i%len(frames)], interp.Bilinear)
+	}
+}
+
+func BenchmarkTransformPlanBatch(b *testing.B) {
+	a, r, frames := benchPlanSetup()
+	dst := image.NewRGBA(r)
+	p, _ := Precompute(a, r, r)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Transform(dst, frames[i%len(frames)])
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/convolve"
+	"image"
+)
+
+// binomial5 are the weights of the 5-tap binomial approximation of a
+// Gaussian used to build pyramids.
+var binomial5 = []float64{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+// pyramidKernel blurs with binomial5 along both axes.
+var pyramidKernel = &convolve.SeparableKernel{X: binomial5, Y: binomial5}
+
+// GaussianPyramid returns up to levels images, each half the size of the
+// one before, rounding up. The first is a copy of src at the origin, and
+// each following level is the previous one blurred with a 5-tap Gaussian
+// and then sampled at every other pixel. The pyramid stops early once a
+// level is a single pixel. It returns nil if src is nil or levels is not
+// positive.
+func GaussianPyramid(src image.Image, levels int) []*image.RGBA {
+	if src == nil || levels < 1 {
+		return nil
+	}
+	m := crop(src, src.Bounds())
+	p := []*image.RGBA{m}
+	for len(p) < levels {
+		if b := m.Bounds(); b.Dx() <= 1 && b.Dy() <= 1 {
+			break
+		}
+		var err error
+		if m, err = pyrDown(m); err != nil {
+			return nil
+		}
+		p = append(p, m)
+	}
+	return p
+}
+
+// pyrDown blurs m, which must be at the origin, and halves its size.
+func pyrDown(m *image.RGBA) (*image.RGBA, error) {
+	b := m.Bounds()
+	blurred := image.NewRGBA(b)
+	if err := convolve.Convolve(blurred, m, pyramidKernel); err != nil {
+		return nil, err
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, (b.Dx()+1)/2, (b.Dy()+1)/2))
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			so, do := 2*y*blurred.Stride+2*x*4, y*dst.Stride+x*4
+			copy(dst.Pix[do:do+4], blurred.Pix[so:so+4])
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/convolve"
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"testing"
+
+	_ "image/png"
+)
+
+func TestGaussianPyramid(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := GaussianPyramid(src, 4)
+	sizes := []image.Point{{400, 600}, {200, 300}, {100, 150}, {50, 75}}
+	if len(p) != len(sizes) {
+		t.Fatalf("got %d levels, want %d", len(p), len(sizes))
+	}
+	for i, m := range p {
+		if got := m.Bounds(); got != (image.Rectangle{Max: sizes[i]}) {
+			t.Errorf("level %d: bounds %v want %v", i, got, sizes[i])
+		}
+	}
+	if err := graphicstest.ImageWithinTolerance(p[0], src, 0); err != nil {
+		t.Errorf("level 0: %v", err)
+	}
+
+	// Each level is the blurred previous level at every other pixel.
+	for i := 1; i < len(p); i++ {
+		blurred := image.NewRGBA(p[i-1].Bounds())
+		if err := convolve.Convolve(blurred, p[i-1], pyramidKernel); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < sizes[i].Y; y++ {
+			for x := 0; x < sizes[i].X; x++ {
+				if got, want := p[i].RGBAAt(x, y), blurred.RGBAAt(2*x, 2*y); got != want {
+					t.Fatalf("level %d (%d, %d): got %v want %v", i, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	// Odd sizes round up, and the pyramid ends at a single pixel.
+	p = GaussianPyramid(image.NewRGBA(image.Rect(0, 0, 5, 3)), 10)
+	var got []image.Point
+	for _, m := range p {
+		got = append(got, m.Bounds().Size())
+	}
+	want := []image.Point{{5, 3}, {3, 2}, {2, 1}, {1, 1}}
+	if len(got) != len(want) {
+		t.Fatalf("odd sizes: got %v want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("odd sizes: got %v want %v", got, want)
+			break
+		}
+	}
+
+	if p := GaussianPyramid(nil, 3); p != nil {
+		t.Errorf("nil src: got %d levels want nil", len(p))
+	}
+}
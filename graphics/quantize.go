@@ -0,0 +1,205 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+// WebSafe is the 216 color web-safe palette: every combination of the
+// channel values 0x00, 0x33, 0x66, 0x99, 0xcc and 0xff, ordered by red,
+// then green, then blue.
+var WebSafe = func() color.Palette {
+	p := make(color.Palette, 0, 216)
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				p = append(p, color.RGBA{uint8(r * 0x33), uint8(g * 0x33), uint8(b * 0x33), 0xff})
+			}
+		}
+	}
+	return p
+}()
+
+// QuantizeToPalette maps each pixel of src to the nearest color of p, with
+// no dithering, and returns the result as a paletted image with the same
+// bounds as src. A paletted image indexes at most 256 colors, so only the
+// first 256 colors of p are used.
+func QuantizeToPalette(src image.Image, p color.Palette) *image.Paletted {
+	if len(p) > 256 {
+		p = p[:256]
+	}
+	b := src.Bounds()
+	dst := image.NewPaletted(b, p)
+	if len(p) == 0 {
+		return dst
+	}
+	// Runs of identical colors are common, so remember the last lookup.
+	var last color.Color
+	var idx uint8
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := src.At(x, y)
+			if last == nil || c != last {
+				last, idx = c, uint8(p.Index(c))
+			}
+			dst.Pix[(y-b.Min.Y)*dst.Stride+(x-b.Min.X)] = idx
+		}
+	}
+	return dst
+}
+
+// Palette derives a palette of at most n colors from src using median cut.
+// The distinct colors of src are recursively split, at the weighted median
+// of the channel with the widest range, into n boxes; each box contributes
+// its weighted average color. The result is deterministic.
+func Palette(src image.Image, n int) color.Palette {
+	if n < 1 {
+		return nil
+	}
+
+	hist := make(map[color.RGBA]int)
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			hist[color.RGBAModel.Convert(src.At(x, y)).(color.RGBA)]++
+		}
+	}
+	if len(hist) == 0 {
+		return nil
+	}
+	colors := make([]weightedColor, 0, len(hist))
+	for c, w := range hist {
+		colors = append(colors, weightedColor{c, w})
+	}
+	sort.Sort(byChannel{colors, -1})
+
+	boxes := []colorBox{{colors}}
+	for len(boxes) < n {
+		// Split the box with the widest channel range.
+		best, bestRange := -1, 0
+		for i, box := range boxes {
+			if len(box.colors) < 2 {
+				continue
+			}
+			if _, r := box.widest(); r > bestRange {
+				best, bestRange = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		lo, hi := boxes[best].split()
+		boxes[best] = lo
+		boxes = append(boxes, hi)
+	}
+
+	p := make(color.Palette, len(boxes))
+	for i, box := range boxes {
+		p[i] = box.average()
+	}
+	return p
+}
+
+type weightedColor struct {
+	c color.RGBA
+	w int
+}
+
+func channel(c color.RGBA, ch int) uint8 {
+	switch ch {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	}
+	return c.A
+}
+
+// byChannel sorts colors by channel ch, breaking ties by the full RGBA
+// value. A negative ch sorts by the full RGBA value alone.
+type byChannel struct {
+	colors []weightedColor
+	ch     int
+}
+
+func (s byChannel) Len() int      { return len(s.colors) }
+func (s byChannel) Swap(i, j int) { s.colors[i], s.colors[j] = s.colors[j], s.colors[i] }
+func (s byChannel) Less(i, j int) bool {
+	ci, cj := s.colors[i].c, s.colors[j].c
+	if s.ch >= 0 && channel(ci, s.ch) != channel(cj, s.ch) {
+		return channel(ci, s.ch) < channel(cj, s.ch)
+	}
+	for ch := 0; ch < 4; ch++ {
+		if channel(ci, ch) != channel(cj, ch) {
+			return channel(ci, ch) < channel(cj, ch)
+		}
+	}
+	return false
+}
+
+type colorBox struct {
+	colors []weightedColor
+}
+
+// widest returns the channel with the largest range, and that range.
+func (b colorBox) widest() (ch, r int) {
+	for c := 0; c < 4; c++ {
+		lo, hi := 0xff, 0
+		for _, wc := range b.colors {
+			v := int(channel(wc.c, c))
+			if v < lo {
+				lo = v
+			}
+			if v > hi {
+				hi = v
+			}
+		}
+		if hi-lo > r {
+			ch, r = c, hi-lo
+		}
+	}
+	return ch, r
+}
+
+// split divides b at the weighted median of its widest channel. Both
+// halves are non-empty.
+func (b colorBox) split() (lo, hi colorBox) {
+	ch, _ := b.widest()
+	sort.Sort(byChannel{b.colors, ch})
+	total := 0
+	for _, wc := range b.colors {
+		total += wc.w
+	}
+	i, sum := 0, 0
+	for ; i < len(b.colors)-1; i++ {
+		sum += b.colors[i].w
+		if 2*sum >= total {
+			break
+		}
+	}
+	return colorBox{b.colors[:i+1]}, colorBox{b.colors[i+1:]}
+}
+
+func (b colorBox) average() color.RGBA {
+	var r, g, bl, a, n int
+	for _, wc := range b.colors {
+		r += int(wc.c.R) * wc.w
+		g += int(wc.c.G) * wc.w
+		bl += int(wc.c.B) * wc.w
+		a += int(wc.c.A) * wc.w
+		n += wc.w
+	}
+	return color.RGBA{
+		uint8((r + n/2) / n),
+		uint8((g + n/2) / n),
+		uint8((bl + n/2) / n),
+		uint8((a + n/2) / n),
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestWebSafe(t *testing.T) {
+	if len(WebSafe) != 216 {
+		t.Fatalf("got %d colors want 216", len(WebSafe))
+	}
+	if got := WebSafe[215]; got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("last color: got %v want white", got)
+	}
+}
+
+func TestQuantizeToPalette(t *testing.T) {
+	p := color.Palette{
+		color.RGBA{0x00, 0x00, 0x00, 0xff},
+		color.RGBA{0xff, 0x00, 0x00, 0xff},
+		color.RGBA{0x00, 0xff, 0x00, 0xff},
+		color.RGBA{0x00, 0x00, 0xff, 0xff},
+		color.RGBA{0xff, 0xff, 0xff, 0xff},
+	}
+	tests := []struct {
+		c    color.RGBA
+		want uint8
+	}{
+		{color.RGBA{0xe0, 0x10, 0x20, 0xff}, 1},
+		{color.RGBA{0x20, 0xd0, 0x00, 0xff}, 2},
+		{color.RGBA{0x00, 0x30, 0xc0, 0xff}, 3},
+		{color.RGBA{0x20, 0x20, 0x20, 0xff}, 0},
+		{color.RGBA{0xe0, 0xe0, 0xf0, 0xff}, 4},
+	}
+
+	src := image.NewRGBA(image.Rect(2, 3, 2+len(tests), 4))
+	for i, tt := range tests {
+		src.SetRGBA(2+i, 3, tt.c)
+	}
+	dst := QuantizeToPalette(src, p)
+	if !dst.Bounds().Eq(src.Bounds()) {
+		t.Fatalf("got bounds %v want %v", dst.Bounds(), src.Bounds())
+	}
+	for i, tt := range tests {
+		if got := dst.ColorIndexAt(2+i, 3); got != tt.want {
+			t.Errorf("%v: got index %d want %d", tt.c, got, tt.want)
+		}
+	}
+
+	ws := QuantizeToPalette(src, WebSafe)
+	if got := ws.At(2, 3); got != (color.RGBA{0xcc, 0x00, 0x33, 0xff}) {
+		t.Errorf("web-safe: got %v want {0xcc 0x00 0x33 0xff}", got)
+	}
+}
+
+func TestQuantizeToPaletteLarge(t *testing.T) {
+	// Only the first 256 colors can be indexed, so the exact match at
+	// index 260 is unused and the nearest color among the first 256 wins.
+	p := make(color.Palette, 300)
+	for i := range p {
+		if i < 256 {
+			p[i] = color.RGBA{uint8(i), 0x00, 0x00, 0xff}
+		} else {
+			p[i] = color.RGBA{0x00, 0xff, uint8(i), 0xff}
+		}
+	}
+	src := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	src.Set(0, 0, p[260])
+	dst := QuantizeToPalette(src, p)
+	if len(dst.Palette) != 256 {
+		t.Errorf("got %d palette colors want 256", len(dst.Palette))
+	}
+	if got := dst.ColorIndexAt(0, 0); got != 0 {
+		t.Errorf("got index %d want 0", got)
+	}
+}
+
+func TestPalette(t *testing.T) {
+	dominant := []color.RGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0x80, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+	}
+	src := image.NewRGBA(image.Rect(0, 0, 30, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 30; x++ {
+			src.SetRGBA(x, y, dominant[x/10])
+		}
+	}
+
+	p := Palette(src, 3)
+	if len(p) != 3 {
+		t.Fatalf("got %d colors want 3", len(p))
+	}
+	for _, c := range dominant {
+		if got := p.Convert(c); got != c {
+			t.Errorf("%v missing from palette %v", c, p)
+		}
+	}
+
+	// A palette larger than the number of distinct colors is truncated.
+	if got := len(Palette(src, 8)); got != 3 {
+		t.Errorf("got %d colors want 3", got)
+	}
+
+	// The result is deterministic.
+	noise := NewNoise(16, 16, 3)
+	p0, p1 := Palette(noise, 16), Palette(noise, 16)
+	for i := range p0 {
+		if p0[i] != p1[i] {
+			t.Fatalf("palette %d differs: %v vs %v", i, p0[i], p1[i])
+		}
+	}
+}
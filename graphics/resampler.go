@@ -0,0 +1,365 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Resampler is a separable resampling filter, for use with Resize, or with
+// Affine.Transform and the other functions taking an interp.Interp by way
+// of ResamplerInterp.
+//
+// Kernel returns the weight of a source sample at the distance x, in
+// source pixels, from the point being sampled. It must be zero for |x|
+// beyond Support. When shrinking, the kernel is stretched by the scale
+// factor so every source pixel contributes, and the weights of each
+// output sample are normalized to sum to one.
+type Resampler interface {
+	Kernel(x float64) float64
+	Support() float64
+}
+
+// The built-in resamplers.
+var (
+	// NearestResampler takes the nearest source pixel when enlarging,
+	// and is a box filter when shrinking.
+	NearestResampler Resampler = nearestResampler{}
+	// BilinearResampler is the triangle, or tent, filter.
+	BilinearResampler Resampler = bilinearResampler{}
+	// BicubicResampler is the Catmull-Rom cubic spline, which is sharper
+	// than BilinearResampler but can ring at hard edges.
+	BicubicResampler Resampler = bicubicResampler{}
+	// HermiteResampler is the cubic Hermite spline with the support of
+	// BilinearResampler. It is sharper than BilinearResampler when
+	// shrinking, without the ringing of BicubicResampler or
+	// LanczosResampler, which suits moderate reductions of line art and
+	// screenshots.
+	HermiteResampler Resampler = hermiteResampler{}
+	// LanczosResampler is the three lobed Lanczos windowed sinc, which
+	// keeps the most detail but rings the most.
+	LanczosResampler Resampler = lanczosResampler{}
+)
+
+type nearestResampler struct{}
+
+func (nearestResampler) Support() float64 { return 0.5 }
+
+func (nearestResampler) Kernel(x float64) float64 {
+	// The interval is half open so that a sample exactly between two
+	// source pixels takes just one of them.
+	if x > -0.5 && x <= 0.5 {
+		return 1
+	}
+	return 0
+}
+
+type bilinearResampler struct{}
+
+func (bilinearResampler) Support() float64 { return 1 }
+
+func (bilinearResampler) Kernel(x float64) float64 {
+	if x = math.Abs(x); x < 1 {
+		return 1 - x
+	}
+	return 0
+}
+
+type hermiteResampler struct{}
+
+func (hermiteResampler) Support() float64 { return 1 }
+
+func (hermiteResampler) Kernel(x float64) float64 {
+	if x = math.Abs(x); x < 1 {
+		return (2*x-3)*x*x + 1
+	}
+	return 0
+}
+
+type bicubicResampler struct{}
+
+func (bicubicResampler) Support() float64 { return 2 }
+
+func (bicubicResampler) Kernel(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	}
+	return 0
+}
+
+type lanczosResampler struct{}
+
+func (lanczosResampler) Support() float64 { return 3 }
+
+func (lanczosResampler) Kernel(x float64) float64 {
+	x = math.Abs(x)
+	if x == 0 {
+		return 1
+	}
+	if x >= 3 {
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}
+
+// ResamplerInterp returns an interpolator that weighs the source pixels
+// around each point with the kernel of r, so that a Resampler can be used
+// with Affine.Transform. Unlike Resize, it does not stretch the kernel when
+// a transform shrinks, so it suits rotations and moderate scales. Pixels
+// beyond the edges of src repeat those at the edges, and the weights are
+// normalized to sum to one.
+func ResamplerInterp(r Resampler) interp.Interp {
+	return resamplerInterp{r}
+}
+
+type resamplerInterp struct {
+	r Resampler
+}
+
+// taps returns the weights of the source samples, starting at start, that
+// contribute to the sample at x, where pixel centers are at half
+// integers.
+func (ri resamplerInterp) taps(x float64) (start int, w []float64) {
+	s := ri.r.Support()
+	start = int(math.Ceil(x - 0.5 - s))
+	w = make([]float64, int(math.Floor(x-0.5+s))-start+1)
+	sum := 0.0
+	for j := range w {
+		w[j] = ri.r.Kernel(float64(start+j) + 0.5 - x)
+		sum += w[j]
+	}
+	if sum != 0 {
+		for j := range w {
+			w[j] /= sum
+		}
+	}
+	return start, w
+}
+
+// sum returns the weighted sum at (x, y) of the premultiplied channels
+// that at returns for the pixels of b.
+func (ri resamplerInterp) sum(b image.Rectangle, x, y float64, at func(x, y int) [4]float64) (c [4]float64) {
+	x0, wx := ri.taps(x)
+	y0, wy := ri.taps(y)
+	for j, ky := range wy {
+		if ky == 0 {
+			continue
+		}
+		sy := clampInt(y0+j, b.Min.Y, b.Max.Y-1)
+		for i, kx := range wx {
+			if kx == 0 {
+				continue
+			}
+			p := at(clampInt(x0+i, b.Min.X, b.Max.X-1), sy)
+			for k := range c {
+				c[k] += kx * ky * p[k]
+			}
+		}
+	}
+	return c
+}
+
+func (ri resamplerInterp) Interp(src image.Image, x, y float64) color.Color {
+	if src, ok := src.(*image.RGBA); ok {
+		return ri.RGBA(src, x, y)
+	}
+	b := src.Bounds()
+	if b.Empty() {
+		return color.RGBA64{}
+	}
+	c := ri.sum(b, x, y, func(x, y int) [4]float64 {
+		r, g, b, a := src.At(x, y).RGBA()
+		return [4]float64{float64(r), float64(g), float64(b), float64(a)}
+	})
+	// Kernels with negative lobes can overshoot, so keep the colors
+	// within the premultiplied alpha.
+	a := math.Max(0, math.Min(0xffff, c[3]))
+	ch := func(v float64) uint16 {
+		return uint16(math.Max(0, math.Min(a, v)) + 0.5)
+	}
+	return color.RGBA64{ch(c[0]), ch(c[1]), ch(c[2]), ch(a)}
+}
+
+func (ri resamplerInterp) RGBA(src *image.RGBA, x, y float64) color.RGBA {
+	b := src.Bounds()
+	if b.Empty() {
+		return color.RGBA{}
+	}
+	c := ri.sum(b, x, y, func(x, y int) [4]float64 {
+		p := src.Pix[src.PixOffset(x, y):]
+		return [4]float64{float64(p[0]), float64(p[1]), float64(p[2]), float64(p[3])}
+	})
+	a := clamp8(c[3])
+	return color.RGBA{
+		clampAlpha(c[0], float64(a)),
+		clampAlpha(c[1], float64(a)),
+		clampAlpha(c[2], float64(a)),
+		a,
+	}
+}
+
+// filterTaps are the weights of the contiguous source samples, starting at
+// start, that contribute to one destination sample.
+type filterTaps struct {
+	start   int
+	weights []float64
+}
+
+// resampleTaps returns the taps of r for resampling n source samples to m
+// destination samples, following ImageMagick's resize.c.
+func resampleTaps(r Resampler, m, n int) []filterTaps {
+	factor := float64(m) / float64(n)
+	scale := math.Max(1/factor, 1)
+	support := r.Support() * scale
+	taps := make([]filterTaps, m)
+	for x := range taps {
+		center := (float64(x) + 0.5) / factor
+		start := int(math.Max(center-support+0.5, 0))
+		stop := int(math.Min(center+support+0.5, float64(n)))
+		w := make([]float64, stop-start)
+		sum := 0.0
+		for j := range w {
+			w[j] = r.Kernel((float64(start+j) + 0.5 - center) / scale)
+			sum += w[j]
+		}
+		if sum != 0 {
+			for j := range w {
+				w[j] /= sum
+			}
+		}
+		taps[x] = filterTaps{start, w}
+	}
+	return taps
+}
+
+// resampleTapCount returns the number of source samples r weighs to scale
+// an image with bounds sb to db.
+func resampleTapCount(r Resampler, db, sb image.Rectangle) int64 {
+	var n int64
+	for _, tp := range resampleTaps(r, db.Dx(), sb.Dx()) {
+		n += int64(len(tp.weights) * sb.Dy())
+	}
+	for _, tp := range resampleTaps(r, db.Dy(), sb.Dy()) {
+		n += int64(len(tp.weights) * db.Dx())
+	}
+	return n
+}
+
+// resampleScaler returns the scaler that resamples with r, rows and then
+// columns. It works on premultiplied alpha.
+func resampleScaler(r Resampler) scaler {
+	return func(dst draw.Image, src image.Image, cancel <-chan struct{}) error {
+		return resample(dst, src, r, cancel)
+	}
+}
+
+func resample(dst draw.Image, src image.Image, r Resampler, cancel <-chan struct{}) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	db, sb := dst.Bounds(), src.Bounds()
+	if db.Empty() || sb.Empty() {
+		return nil
+	}
+	xtaps := resampleTaps(r, db.Dx(), sb.Dx())
+	ytaps := resampleTaps(r, db.Dy(), sb.Dy())
+
+	// Gray fast path: a single channel, with no alpha.
+	if d, ok := dst.(*image.Gray); ok {
+		if s, ok := src.(*image.Gray); ok {
+			return resamplePlane(d.Pix[d.PixOffset(db.Min.X, db.Min.Y):], d.Stride,
+				s.Pix[s.PixOffset(sb.Min.X, sb.Min.Y):], s.Stride, sb.Dy(), 1, xtaps, ytaps, cancel)
+		}
+	}
+
+	s := crop(src, sb)
+	d, ok := dst.(*image.RGBA)
+	if !ok {
+		d = image.NewRGBA(db)
+	}
+	err := resamplePlane(d.Pix[d.PixOffset(db.Min.X, db.Min.Y):], d.Stride,
+		s.Pix, s.Stride, sb.Dy(), 4, xtaps, ytaps, cancel)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		draw.Draw(dst, db, d, db.Min, draw.Src)
+	}
+	return nil
+}
+
+// resamplePlane resamples the sh rows of the image spix, with n channels
+// per pixel, into dpix with the taps xtaps and ytaps, rows and then
+// columns. Four channels are premultiplied RGBA, one is gray.
+func resamplePlane(dpix []uint8, dstride int, spix []uint8, sstride, sh, n int, xtaps, ytaps []filterTaps, cancel <-chan struct{}) error {
+	dw, dh := len(xtaps), len(ytaps)
+
+	// Resample each source row to the destination width.
+	tmp := make([]float64, sh*dw*n)
+	parallelRows(0, sh, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			row := spix[y*sstride:]
+			out := tmp[y*dw*n : (y+1)*dw*n]
+			for x, tp := range xtaps {
+				o := out[x*n : x*n+n]
+				for j, w := range tp.weights {
+					p := row[(tp.start+j)*n:]
+					for c := range o {
+						o[c] += w * float64(p[c])
+					}
+				}
+			}
+		}
+	})
+	if canceled(cancel) {
+		return ErrCanceled
+	}
+
+	// Resample each column to the destination height.
+	parallelRows(0, dh, func(y0, y1 int) {
+		var sum [4]float64
+		for y := y0; y < y1; y++ {
+			tp := ytaps[y]
+			out := dpix[y*dstride:]
+			for i := 0; i < dw*n; i += n {
+				sum = [4]float64{}
+				for j, w := range tp.weights {
+					p := tmp[(tp.start+j)*dw*n+i:]
+					for c := 0; c < n; c++ {
+						sum[c] += w * p[c]
+					}
+				}
+				if n == 1 {
+					out[i] = clamp8(sum[0])
+					continue
+				}
+				// Negative lobes can push the colors beyond the
+				// premultiplied alpha.
+				a := clamp8(sum[3])
+				out[i+0] = clampAlpha(sum[0], float64(a))
+				out[i+1] = clampAlpha(sum[1], float64(a))
+				out[i+2] = clampAlpha(sum[2], float64(a))
+				out[i+3] = a
+			}
+		}
+	})
+	if canceled(cancel) {
+		return ErrCanceled
+	}
+	return nil
+}
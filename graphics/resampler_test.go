@@ -0,0 +1,175 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// tentResampler is a user-defined triangle filter that counts its calls.
+type tentResampler struct {
+	calls int
+}
+
+func (r *tentResampler) Support() float64 { return 1 }
+
+func (r *tentResampler) Kernel(x float64) float64 {
+	r.calls++
+	return math.Max(0, 1-math.Abs(x))
+}
+
+func TestResampleTaps(t *testing.T) {
+	// Doubling two samples weighs them bilinearly, with the edge samples
+	// replicated.
+	want := []filterTaps{
+		{0, []float64{1}},
+		{0, []float64{0.75, 0.25}},
+		{0, []float64{0.25, 0.75}},
+		{1, []float64{1}},
+	}
+	got := resampleTaps(&tentResampler{}, 4, 2)
+	if len(got) != len(want) {
+		t.Fatalf("got %d taps want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].start != want[i].start || len(got[i].weights) != len(want[i].weights) {
+			t.Errorf("tap %d: got %v want %v", i, got[i], want[i])
+			continue
+		}
+		for j, w := range want[i].weights {
+			if math.Abs(got[i].weights[j]-w) > 1e-12 {
+				t.Errorf("tap %d: got %v want %v", i, got[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestResizeResampler(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	src.SetRGBA(1, 0, color.RGBA{200, 200, 200, 0xff})
+	dst := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	r := &tentResampler{}
+	if err := Resize(dst, src, &ResizeOptions{Resampler: r}); err != nil {
+		t.Fatal(err)
+	}
+	if r.calls == 0 {
+		t.Fatal("resampler not called")
+	}
+	for i, v := range []uint8{0, 50, 150, 200} {
+		if got := dst.RGBAAt(i, 0); got != (color.RGBA{v, v, v, 0xff}) {
+			t.Errorf("sample %d: got %v want %d", i, got, v)
+		}
+	}
+}
+
+func TestResamplerInterp(t *testing.T) {
+	src := NewNoise(16, 16, 1)
+	a := I.Rotate(0.3).Center(8, 8)
+
+	// The triangle filter is bilinear interpolation.
+	want := image.NewRGBA(src.Bounds())
+	if err := a.Transform(want, src, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	got := image.NewRGBA(src.Bounds())
+	if err := a.Transform(got, src, ResamplerInterp(BilinearResampler)); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 1); err != nil {
+		t.Errorf("bilinear: %v", err)
+	}
+	// Likewise on the general path.
+	n := image.NewNRGBA(src.Bounds())
+	copy(n.Pix, src.Pix)
+	for _, pt := range [][2]float64{{3.2, 4.7}, {0.1, 0.2}, {15.9, 8.5}, {15.5, 0.5}} {
+		g := color.RGBA64Model.Convert(ResamplerInterp(BilinearResampler).Interp(n, pt[0], pt[1])).(color.RGBA64)
+		w := color.RGBA64Model.Convert(interp.Bilinear.Interp(n, pt[0], pt[1])).(color.RGBA64)
+		for _, d := range []int{int(g.R) - int(w.R), int(g.G) - int(w.G), int(g.B) - int(w.B), int(g.A) - int(w.A)} {
+			if d < -1 || d > 1 {
+				t.Errorf("general %v: got %v want %v", pt, g, w)
+				break
+			}
+		}
+	}
+
+	// The nearest filter copies whole pixels when doubling.
+	dst := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	if err := I.Scale(2, 2).Transform(dst, src, ResamplerInterp(NearestResampler)); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			if got, want := dst.RGBAAt(x, y), src.RGBAAt(x/2, y/2); got != want {
+				t.Fatalf("nearest (%d, %d): got %v want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestBuiltinResamplers(t *testing.T) {
+	for _, r := range []Resampler{NearestResampler, BilinearResampler, HermiteResampler, BicubicResampler, LanczosResampler} {
+		if k := r.Kernel(0); k != 1 {
+			t.Errorf("%T: Kernel(0) = %v, want 1", r, k)
+		}
+		if s := r.Support() + 1e-9; r.Kernel(s) != 0 || r.Kernel(-s) != 0 {
+			t.Errorf("%T: kernel not zero beyond its support %v", r, r.Support())
+		}
+		// Shrinking a uniform image keeps it uniform.
+		src := newUniformRGBA(image.Rect(0, 0, 9, 9), color.RGBA{0x40, 0x80, 0xc0, 0xff})
+		dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		if err := Resize(dst, src, &ResizeOptions{Resampler: r}); err != nil {
+			t.Fatal(err)
+		}
+		if got := dst.RGBAAt(1, 2); got != src.RGBAAt(0, 0) {
+			t.Errorf("%T: got %v want %v", r, got, src.RGBAAt(0, 0))
+		}
+	}
+}
+
+// totalVariation returns the mean absolute difference between
+// horizontally adjacent samples of the red channel of m, a measure of its
+// sharpness.
+func totalVariation(m *image.RGBA) float64 {
+	b := m.Bounds()
+	sum := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X + 1; x < b.Max.X; x++ {
+			d := int(m.RGBAAt(x, y).R) - int(m.RGBAAt(x-1, y).R)
+			if d < 0 {
+				d = -d
+			}
+			sum += d
+		}
+	}
+	return float64(sum) / float64((b.Dx()-1)*b.Dy())
+}
+
+func TestHermiteResampler(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Reduce by 2.5x with each filter.
+	tv := make(map[Resampler]float64)
+	for _, r := range []Resampler{BilinearResampler, HermiteResampler, LanczosResampler} {
+		dst := image.NewRGBA(image.Rect(0, 0, 160, 240))
+		if err := Resize(dst, src, &ResizeOptions{Resampler: r}); err != nil {
+			t.Fatal(err)
+		}
+		tv[r] = totalVariation(dst)
+	}
+	// Hermite is sharper than bilinear, but less so than Lanczos.
+	if !(tv[BilinearResampler] < tv[HermiteResampler] && tv[HermiteResampler] < tv[LanczosResampler]) {
+		t.Errorf("got sharpness bilinear %.3f, Hermite %.3f, Lanczos %.3f, want increasing",
+			tv[BilinearResampler], tv[HermiteResampler], tv[LanczosResampler])
+	}
+}
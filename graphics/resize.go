@@ -0,0 +1,327 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+	"sort"
+	"time"
+)
+
+// FillMode controls how Resize handles a dst whose aspect ratio differs
+// from that of src.
+type FillMode int
+
+const (
+	// Stretch scales each axis independently so src exactly covers dst,
+	// distorting it if the aspect ratios differ.
+	Stretch FillMode = iota
+	// Fit scales src to fit entirely within dst, preserving its aspect
+	// ratio, and centers it. The uncovered parts of dst are made
+	// transparent.
+	Fit
+	// Cover scales src to cover all of dst, preserving its aspect ratio,
+	// and crops the overflow evenly from both sides.
+	Cover
+)
+
+// ResizeOptions are the resizing parameters.
+// Mode is how src is fitted to dst. The default is Stretch.
+// Filter is the resampling filter. The default is BilinearFilter.
+// Resampler, if non-nil, is a filter to use instead of Filter.
+// Stats, if non-nil, is filled in with statistics about the call.
+// Focus, if not empty, is the region of src, in its co-ordinates, to keep
+// in view when Cover crops: the crop is centered on it as far as the edges
+// of src allow, so it stays whole whenever it fits.
+// SharpenAfter, if positive, is the amount of an unsharp mask applied to
+// the result to restore the crispness lost in shrinking. It is scaled by
+// the reduction: halving the size applies half of it, and the full amount
+// is approached as the reduction grows. Enlarging is not sharpened.
+type ResizeOptions struct {
+	Mode         FillMode
+	Filter       ResizeFilter
+	Resampler    Resampler
+	Stats        *ResizeStats
+	Focus        image.Rectangle
+	SharpenAfter float64
+}
+
+// ResizeStats reports the work done by a call to Resize, for profiling.
+type ResizeStats struct {
+	// Pixels is the number of dst pixels written.
+	Pixels int
+	// Taps is the number of weighted source samples the filter evaluated,
+	// counting each pixel once rather than each channel.
+	Taps int64
+	// Duration is the wall-clock time taken.
+	Duration time.Duration
+}
+
+// Resize produces a resized version of src, drawn onto dst, using the
+// filter given by opt, bilinear interpolation by default. The output size
+// is that of dst, whose bounds need not start at the origin. Every pixel of
+// dst is overwritten, so dst may be a buffer recycled from an earlier call.
+// Stretching an *image.Gray onto an *image.Gray works on the single channel
+// throughout. Colors are interpolated premultiplied by alpha, so the color
+// of transparent pixels does not bleed into the edges of opaque ones.
+func Resize(dst draw.Image, src image.Image, opt *ResizeOptions) error {
+	return resize(dst, src, opt, nil)
+}
+
+// ResizeCancel is like Resize, but stops early and returns ErrCanceled once
+// cancel is closed.
+func ResizeCancel(dst draw.Image, src image.Image, opt *ResizeOptions, cancel <-chan struct{}) error {
+	return resize(dst, src, opt, cancel)
+}
+
+func resize(dst draw.Image, src image.Image, opt *ResizeOptions, cancel <-chan struct{}) (err error) {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	mode, filter := Stretch, BilinearFilter
+	var focus image.Rectangle
+	if opt != nil {
+		mode, filter, focus = opt.Mode, opt.Filter, opt.Focus
+	}
+	sc, err := filter.scaler()
+	if err != nil {
+		return err
+	}
+	taps := filter.taps
+	if opt != nil && opt.Resampler != nil {
+		r := opt.Resampler
+		sc = resampleScaler(r)
+		taps = func(db, sb image.Rectangle) int64 { return resampleTapCount(r, db, sb) }
+	}
+
+	db, sb := dst.Bounds(), src.Bounds()
+	if opt != nil && opt.Stats != nil {
+		stats, start := opt.Stats, time.Now()
+		*stats = ResizeStats{}
+		inner := sc
+		sc = func(dst draw.Image, src image.Image, cancel <-chan struct{}) error {
+			stats.Taps += taps(dst.Bounds(), src.Bounds())
+			return inner(dst, src, cancel)
+		}
+		defer func() {
+			stats.Pixels = db.Dx() * db.Dy()
+			stats.Duration = time.Since(start)
+		}()
+	}
+	if db.Empty() {
+		return nil
+	}
+	if sb.Empty() {
+		draw.Draw(dst, db, image.Transparent, image.ZP, draw.Src)
+		return nil
+	}
+	if opt != nil && opt.SharpenAfter > 0 {
+		if r := reduction(mode, db, sb); r > 1 {
+			amount := opt.SharpenAfter * (1 - 1/r)
+			defer func() {
+				if err == nil {
+					err = UnsharpMask(dst, dst, &UnsharpOptions{Amount: amount})
+				}
+			}()
+		}
+	}
+
+	switch mode {
+	case Stretch:
+		return sc(dst, src, cancel)
+	case Fit:
+		w, h := fitSize(sb.Dx(), sb.Dy(), db.Dx(), db.Dy())
+		buf := image.NewRGBA(image.Rect(0, 0, w, h))
+		if err := sc(buf, src, cancel); err != nil {
+			return err
+		}
+		draw.Draw(dst, db, image.Transparent, image.ZP, draw.Src)
+		pt := db.Min.Add(image.Pt((db.Dx()-w)/2, (db.Dy()-h)/2))
+		draw.Draw(dst, buf.Bounds().Add(pt), buf, image.ZP, draw.Src)
+		return nil
+	case Cover:
+		return thumbnail(dst, src, sc, focus, cancel)
+	}
+	return errors.New("graphics: unknown fill mode")
+}
+
+// reduction returns the factor by which resizing from sb to db with mode
+// shrinks the content of src.
+func reduction(mode FillMode, db, sb image.Rectangle) float64 {
+	rx := float64(sb.Dx()) / float64(db.Dx())
+	ry := float64(sb.Dy()) / float64(db.Dy())
+	switch mode {
+	case Fit:
+		return math.Max(rx, ry)
+	case Cover:
+		return math.Min(rx, ry)
+	}
+	return math.Sqrt(rx * ry)
+}
+
+// ResizeMaxPixels returns a copy of src, scaled down so that its area is at
+// most maxPixels while preserving its aspect ratio. It never scales up: if
+// src is already within budget, an unscaled copy is returned.
+func ResizeMaxPixels(src image.Image, maxPixels int) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	if maxPixels < 1 {
+		return nil, errors.New("graphics: maxPixels must be positive")
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w*h <= maxPixels {
+		return crop(src, b), nil
+	}
+
+	f := math.Sqrt(float64(maxPixels) / float64(w*h))
+	nw := int(math.Max(1, math.Floor(float64(w)*f)))
+	nh := int(math.Max(1, math.Floor(float64(h)*f)))
+	// An extremely thin source may still be over budget once its short
+	// side is clamped to one pixel.
+	for nw*nh > maxPixels {
+		if nw > nh {
+			nw--
+		} else {
+			nh--
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	if err := Scale(dst, src); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// ResizeByFactor returns src scaled by fx horizontally and fy vertically,
+// at the origin. Each dimension is rounded to the nearest pixel, and is at
+// least one pixel unless src is empty on that axis. Shrinking on either
+// axis uses the triangle filter, which averages every source pixel;
+// otherwise the bicubic resampler is used, which keeps enlarged edges
+// crisp. The factors must be positive and finite.
+func ResizeByFactor(src image.Image, fx, fy float64) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	for _, f := range []float64{fx, fy} {
+		if !(f > 0) || math.IsInf(f, 1) {
+			return nil, errors.New("graphics: scale factor must be positive and finite")
+		}
+	}
+	b := src.Bounds()
+	size := func(n int, f float64) int {
+		if n == 0 {
+			return 0
+		}
+		return int(math.Max(1, math.Floor(float64(n)*f+0.5)))
+	}
+	return resizeTo(src, size(b.Dx(), fx), size(b.Dy(), fy))
+}
+
+// ResizeWidth returns src scaled to w pixels wide, at the origin, with the
+// height that best preserves its aspect ratio, of at least one pixel. The
+// filter is chosen as by ResizeByFactor.
+func ResizeWidth(src image.Image, w int) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	if w < 1 {
+		return nil, errors.New("graphics: width must be positive")
+	}
+	b := src.Bounds()
+	if b.Empty() {
+		return nil, ErrEmptySrc
+	}
+	return resizeTo(src, w, aspectSize(b.Dy(), w, b.Dx()))
+}
+
+// ResizeHeight is like ResizeWidth, scaling src to h pixels high.
+func ResizeHeight(src image.Image, h int) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	if h < 1 {
+		return nil, errors.New("graphics: height must be positive")
+	}
+	b := src.Bounds()
+	if b.Empty() {
+		return nil, ErrEmptySrc
+	}
+	return resizeTo(src, aspectSize(b.Dx(), h, b.Dy()), h)
+}
+
+// aspectSize returns n scaled by num/den, rounded to the nearest pixel and
+// at least one.
+func aspectSize(n, num, den int) int {
+	return int(math.Max(1, math.Floor(float64(n)*float64(num)/float64(den)+0.5)))
+}
+
+// resizeTo returns src scaled to w by h, at the origin, with the triangle
+// filter if it shrinks on either axis and the bicubic resampler otherwise.
+func resizeTo(src image.Image, w, h int) (*image.RGBA, error) {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	opt := &ResizeOptions{Resampler: BicubicResampler}
+	if w < b.Dx() || h < b.Dy() {
+		opt = &ResizeOptions{Filter: TriangleFilter}
+	}
+	if err := Resize(dst, src, opt); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// ResizeMultiple returns src scaled with the triangle filter to each of
+// sizes, in the same order. The sizes are produced progressively: the
+// largest is scaled from src, and each smaller one from the smallest
+// result already made that is at least as large on both axes, which does
+// less work than scaling each from src. Sizes with a non-positive
+// dimension give empty images. It returns nil if src is nil or cannot be
+// resampled.
+func ResizeMultiple(src image.Image, sizes []image.Point) []*image.RGBA {
+	if src == nil {
+		return nil
+	}
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := sizes[order[i]], sizes[order[j]]
+		return a.X*a.Y > b.X*b.Y
+	})
+
+	out := make([]*image.RGBA, len(sizes))
+	var done []*image.RGBA
+	for _, i := range order {
+		w, h := max0(sizes[i].X), max0(sizes[i].Y)
+		if w == 0 || h == 0 {
+			w, h = 0, 0
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		from := src
+		for _, m := range done {
+			// done is in decreasing order of area, so the last fit
+			// is the smallest.
+			if mb := m.Bounds(); mb.Dx() >= w && mb.Dy() >= h {
+				from = m
+			}
+		}
+		if err := resample(dst, from, BilinearResampler, nil); err != nil {
+			return nil
+		}
+		out[i] = dst
+		done = append(done, dst)
+	}
+	return out
+}
@@ -0,0 +1,403 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"fmt"
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+var resizeStripes = []color.RGBA{
+	{0xff, 0x00, 0x00, 0xff},
+	{0x00, 0xff, 0x00, 0xff},
+	{0x00, 0x00, 0xff, 0xff},
+	{0xff, 0xff, 0xff, 0xff},
+}
+
+// newStripes returns an 8x4 image of four 2 pixel wide vertical stripes.
+func newStripes() *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			m.SetRGBA(x, y, resizeStripes[x/2])
+		}
+	}
+	return m
+}
+
+func checkRows(t *testing.T, desc string, m *image.RGBA, want [][]color.RGBA) {
+	for y, row := range want {
+		for x, c := range row {
+			if got := m.RGBAAt(x, y); got != c {
+				t.Errorf("%s: (%d, %d) got %v want %v", desc, x, y, got, c)
+			}
+		}
+	}
+}
+
+func TestResizeFillMode(t *testing.T) {
+	a, b, c, d := resizeStripes[0], resizeStripes[1], resizeStripes[2], resizeStripes[3]
+	z := color.RGBA{}
+	tests := []struct {
+		desc string
+		mode FillMode
+		want [][]color.RGBA
+	}{
+		{"stretch", Stretch, [][]color.RGBA{
+			{a, b, c, d},
+			{a, b, c, d},
+			{a, b, c, d},
+			{a, b, c, d},
+		}},
+		{"fit", Fit, [][]color.RGBA{
+			{z, z, z, z},
+			{a, b, c, d},
+			{a, b, c, d},
+			{z, z, z, z},
+		}},
+		{"cover", Cover, [][]color.RGBA{
+			{b, b, c, c},
+			{b, b, c, c},
+			{b, b, c, c},
+			{b, b, c, c},
+		}},
+	}
+
+	for _, tt := range tests {
+		dst := newUniformRGBA(image.Rect(0, 0, 4, 4), color.Black)
+		if err := Resize(dst, newStripes(), &ResizeOptions{Mode: tt.mode}); err != nil {
+			t.Errorf("%s: %v", tt.desc, err)
+			continue
+		}
+		checkRows(t, tt.desc, dst, tt.want)
+	}
+}
+
+func TestResizeEmpty(t *testing.T) {
+	empty := image.NewRGBA(image.Rect(0, 0, 0, 0))
+	if err := Resize(empty, empty, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResizeMaxPixels(t *testing.T) {
+	const budget = 100000
+	pano := NewGradient(4000, 500, color.Black, color.White)
+	m, err := ResizeMaxPixels(pano, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, h := m.Bounds().Dx(), m.Bounds().Dy()
+	if n := w * h; n > budget || n < budget*97/100 {
+		t.Errorf("got %dx%d = %d pixels, want just under %d", w, h, n, budget)
+	}
+	if r := float64(w) / float64(h); r < 7.9 || r > 8.1 {
+		t.Errorf("got aspect ratio %.3f want 8", r)
+	}
+
+	// Sources within budget are not upscaled.
+	small := NewNoise(10, 10, 1)
+	m, err = ResizeMaxPixels(small, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.Bounds(); !got.Eq(small.Bounds()) {
+		t.Errorf("got bounds %v want %v", got, small.Bounds())
+	}
+}
+
+func TestResizeReusedBuffer(t *testing.T) {
+	// The pooled buffer is a view into a larger allocation, not at the
+	// origin.
+	pool := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	buf := pool.SubImage(image.Rect(8, 8, 40, 32)).(*image.RGBA)
+
+	first := NewNoise(50, 40, 1)
+	second := NewGradient(20, 30, color.Transparent, color.RGBA{0, 0, 0x80, 0x80})
+	for _, mode := range []FillMode{Stretch, Fit, Cover} {
+		opt := &ResizeOptions{Mode: mode}
+		if err := Resize(buf, first, opt); err != nil {
+			t.Fatal(err)
+		}
+		if err := Resize(buf, second, opt); err != nil {
+			t.Fatal(err)
+		}
+
+		want := image.NewRGBA(buf.Bounds())
+		if err := Resize(want, second, opt); err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(buf, want, 0); err != nil {
+			t.Errorf("mode %d: %v", mode, err)
+		}
+	}
+
+	// An empty source clears the buffer.
+	if err := Resize(buf, image.NewRGBA(image.Rectangle{}), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(buf, image.NewRGBA(buf.Bounds()), 0); err != nil {
+		t.Errorf("empty src: %v", err)
+	}
+}
+
+func TestResizeStats(t *testing.T) {
+	src := newStripes()
+	for _, mode := range []FillMode{Stretch, Fit, Cover} {
+		for _, filter := range []ResizeFilter{BilinearFilter, TriangleFilter} {
+			var stats ResizeStats
+			dst := image.NewRGBA(image.Rect(3, 5, 8, 11))
+			opt := &ResizeOptions{Mode: mode, Filter: filter, Stats: &stats}
+			if err := Resize(dst, src, opt); err != nil {
+				t.Fatal(err)
+			}
+			if want := 5 * 6; stats.Pixels != want {
+				t.Errorf("mode %d filter %d: pixels %d want %d", mode, filter, stats.Pixels, want)
+			}
+			if stats.Taps <= 0 || stats.Duration < 0 {
+				t.Errorf("mode %d filter %d: stats %+v", mode, filter, stats)
+			}
+		}
+	}
+}
+
+func TestResizeCoverFocus(t *testing.T) {
+	// A 40x10 image, offset from the origin, with a red focus region
+	// near its right edge.
+	src := newUniformRGBA(image.Rect(100, 50, 140, 60), color.RGBA{0, 0, 0xff, 0xff})
+	focus := image.Rect(128, 52, 134, 58)
+	draw.Draw(src, focus, image.NewUniform(color.RGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+
+	// Cropping to a square keeps a 10x10 window of src: the focus, and
+	// not the center, is in view.
+	dst := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	if err := Resize(dst, src, &ResizeOptions{Mode: Cover, Focus: focus}); err != nil {
+		t.Fatal(err)
+	}
+	// The window is centered on the focus, from x = 126 to 136.
+	want := crop(src, image.Rect(126, 50, 136, 60))
+	if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+		t.Error(err)
+	}
+
+	// A focus at the very edge clamps the window to src.
+	edge := image.Rect(138, 50, 140, 60)
+	if err := Resize(dst, src, &ResizeOptions{Mode: Cover, Focus: edge}); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, crop(src, image.Rect(130, 50, 140, 60)), 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResizeMultiple(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := []image.Point{{50, 75}, {200, 300}, {100, 150}, {0, 10}}
+	got := ResizeMultiple(src, sizes)
+	if len(got) != len(sizes) {
+		t.Fatalf("got %d images want %d", len(got), len(sizes))
+	}
+	for i, m := range got[:3] {
+		if want := (image.Rectangle{Max: sizes[i]}); !m.Bounds().Eq(want) {
+			t.Errorf("size %d: got bounds %v want %v", i, m.Bounds(), want)
+		}
+	}
+	if !got[3].Bounds().Empty() {
+		t.Errorf("got bounds %v for a zero width, want empty", got[3].Bounds())
+	}
+
+	// The smallest, made from an intermediate, is close to a direct
+	// resize of src.
+	direct := image.NewRGBA(image.Rect(0, 0, 50, 75))
+	if err := Resize(direct, src, &ResizeOptions{Filter: TriangleFilter}); err != nil {
+		t.Fatal(err)
+	}
+	var sum int
+	for i := range direct.Pix {
+		d := int(direct.Pix[i]) - int(got[0].Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		sum += d
+	}
+	if mean := float64(sum) / float64(len(direct.Pix)); mean > 2 {
+		t.Errorf("mean difference from a direct resize %.2f, want at most 2", mean)
+	}
+
+	if got := ResizeMultiple(nil, sizes); got != nil {
+		t.Errorf("nil src: got %d images want nil", len(got))
+	}
+}
+
+func TestResizeGray(t *testing.T) {
+	g, m := grayGopher(t)
+	for _, opt := range []*ResizeOptions{nil, {Filter: TriangleFilter}, {Resampler: LanczosResampler}} {
+		r := image.Rect(0, 0, 130, 170)
+		gdst := image.NewGray(r)
+		if err := Resize(gdst, g, opt); err != nil {
+			t.Fatal(err)
+		}
+		mdst := image.NewRGBA(r)
+		if err := Resize(mdst, m, opt); err != nil {
+			t.Fatal(err)
+		}
+		checkGrayParity(t, fmt.Sprintf("%+v", opt), gdst, mdst, 0)
+	}
+}
+
+func TestResizeSharpenAfter(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := image.Rect(0, 0, 100, 150)
+	plain := image.NewRGBA(r)
+	if err := Resize(plain, src, &ResizeOptions{Filter: TriangleFilter}); err != nil {
+		t.Fatal(err)
+	}
+	sharp := image.NewRGBA(r)
+	if err := Resize(sharp, src, &ResizeOptions{Filter: TriangleFilter, SharpenAfter: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if p, s := totalVariation(plain), totalVariation(sharp); s <= p {
+		t.Errorf("got edge contrast %.3f sharpened, %.3f plain; want sharpened higher", s, p)
+	}
+
+	// Enlarging is not sharpened.
+	small := crop(src, image.Rect(100, 100, 150, 150))
+	big := image.Rect(0, 0, 100, 100)
+	plain, sharp = image.NewRGBA(big), image.NewRGBA(big)
+	if err := Resize(plain, small, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Resize(sharp, small, &ResizeOptions{SharpenAfter: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(sharp, plain, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResizeTransparentEdges(t *testing.T) {
+	// An opaque red disc on a transparent background whose straight
+	// color, invisible in src, is black.
+	src := image.NewNRGBA(image.Rect(0, 0, 60, 60))
+	for y := 0; y < 60; y++ {
+		for x := 0; x < 60; x++ {
+			dx, dy := float64(x)-29.5, float64(y)-29.5
+			if dx*dx+dy*dy < 20*20 {
+				src.SetNRGBA(x, y, color.NRGBA{0xff, 0, 0, 0xff})
+			}
+		}
+	}
+
+	opts := []*ResizeOptions{
+		nil,
+		{Filter: TriangleFilter},
+		{Resampler: LanczosResampler},
+		{Resampler: BicubicResampler},
+		{Mode: Fit, Resampler: LanczosResampler},
+		{Mode: Cover, Filter: TriangleFilter},
+		{Filter: TriangleFilter, SharpenAfter: 1},
+	}
+	for _, size := range []int{17, 45, 90} {
+		for _, opt := range opts {
+			dst := image.NewNRGBA(image.Rect(0, 0, size, size))
+			if err := Resize(dst, src, opt); err != nil {
+				t.Fatal(err)
+			}
+			// Every visible pixel, however transparent, is still red.
+			for i := 0; i < len(dst.Pix); i += 4 {
+				p := dst.Pix[i : i+4]
+				if p[3] == 0 {
+					continue
+				}
+				if p[0] < 0xf0 || p[1] > 0x10 || p[2] > 0x10 {
+					t.Errorf("size %d %+v: got %v at offset %d, want red", size, opt, p, i)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestResizeByFactor(t *testing.T) {
+	src := NewNoise(40, 30, 1)
+	tests := []struct {
+		fx, fy float64
+		w, h   int
+	}{
+		{0.5, 0.5, 20, 15},
+		{2, 2, 80, 60},
+		{0.5, 2, 20, 60},
+		{1.0 / 3, 0.01, 13, 1},
+	}
+	for _, tt := range tests {
+		m, err := ResizeByFactor(src, tt.fx, tt.fy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := image.Rect(0, 0, tt.w, tt.h); !m.Bounds().Eq(want) {
+			t.Errorf("factors %v, %v: got bounds %v want %v", tt.fx, tt.fy, m.Bounds(), want)
+		}
+	}
+
+	// Doubling a flat image keeps it flat.
+	flat := newUniformRGBA(image.Rect(0, 0, 5, 5), color.RGBA{0x30, 0x60, 0x90, 0xff})
+	m, err := ResizeByFactor(flat, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(m, newUniformRGBA(m.Bounds(), flat.RGBAAt(0, 0)), 0); err != nil {
+		t.Error(err)
+	}
+
+	for _, f := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := ResizeByFactor(src, f, 1); err == nil {
+			t.Errorf("factor %v: got no error", f)
+		}
+	}
+}
+
+func TestResizeWidthHeight(t *testing.T) {
+	landscape, portrait := NewNoise(400, 300, 1), NewNoise(300, 400, 2)
+	tests := []struct {
+		desc   string
+		src    image.Image
+		resize func(image.Image, int) (*image.RGBA, error)
+		n      int
+		want   image.Point
+	}{
+		{"landscape width", landscape, ResizeWidth, 200, image.Pt(200, 150)},
+		{"landscape height", landscape, ResizeHeight, 100, image.Pt(133, 100)},
+		{"portrait width", portrait, ResizeWidth, 100, image.Pt(100, 133)},
+		{"portrait height", portrait, ResizeHeight, 800, image.Pt(600, 800)},
+		{"sliver", landscape, ResizeWidth, 1, image.Pt(1, 1)},
+	}
+	for _, tt := range tests {
+		m, err := tt.resize(tt.src, tt.n)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.desc, err)
+		}
+		if got := m.Bounds(); got.Min != (image.Point{}) || got.Size() != tt.want {
+			t.Errorf("%s: got bounds %v want size %v at the origin", tt.desc, got, tt.want)
+		}
+	}
+
+	if _, err := ResizeWidth(landscape, 0); err == nil {
+		t.Error("zero width: got no error")
+	}
+	if _, err := ResizeHeight(image.NewRGBA(image.Rectangle{}), 10); err != ErrEmptySrc {
+		t.Errorf("empty src: got %v want %v", err, ErrEmptySrc)
+	}
+}
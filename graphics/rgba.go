@@ -0,0 +1,112 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// toRGBA returns src as an *image.RGBA with the same bounds. If src is
+// already an *image.RGBA it is returned as is, otherwise it is copied.
+func toRGBA(src image.Image) *image.RGBA {
+	if m, ok := src.(*image.RGBA); ok {
+		return m
+	}
+	b := src.Bounds()
+	m := image.NewRGBA(b)
+	draw.Draw(m, b, src, b.Min, draw.Src)
+	return m
+}
+
+// newBuffer returns a scratch image with bounds r for results bound for
+// dst: an *image.Gray if dst and src are both gray, and otherwise an
+// *image.RGBA.
+func newBuffer(dst draw.Image, src image.Image, r image.Rectangle) draw.Image {
+	if _, ok := dst.(*image.Gray); ok {
+		if _, ok := src.(*image.Gray); ok {
+			return image.NewGray(r)
+		}
+	}
+	return image.NewRGBA(r)
+}
+
+// aliases reports whether dst and src share pixel memory, as when they are
+// the same image or sub-images of one image.
+func aliases(dst draw.Image, src image.Image) bool {
+	switch d := dst.(type) {
+	case *image.RGBA:
+		if s, ok := src.(*image.RGBA); ok {
+			return sameArray(d.Pix, s.Pix)
+		}
+	case *image.Gray:
+		if s, ok := src.(*image.Gray); ok {
+			return sameArray(d.Pix, s.Pix)
+		}
+	}
+	return false
+}
+
+// sameArray reports whether a and b are slices of the same array. Slices
+// made by SubImage extend to the end of the array, so they share their
+// last element of capacity.
+func sameArray(a, b []uint8) bool {
+	a, b = a[:cap(a)], b[:cap(b)]
+	return len(a) > 0 && len(b) > 0 && &a[len(a)-1] == &b[len(b)-1]
+}
+
+// clone returns a copy of src with the same bounds, keeping the types
+// *image.Gray and *image.RGBA, and otherwise as an *image.RGBA.
+func clone(src image.Image) image.Image {
+	b := src.Bounds()
+	var m draw.Image = image.NewRGBA(b)
+	if _, ok := src.(*image.Gray); ok {
+		m = image.NewGray(b)
+	}
+	draw.Draw(m, b, src, b.Min, draw.Src)
+	return m
+}
+
+// crop returns a copy of the r region of src, translated to the origin.
+func crop(src image.Image, r image.Rectangle) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(m, m.Bounds(), src, r.Min, draw.Src)
+	return m
+}
+
+// round8 returns c with its channels rounded to the nearest 8-bit value.
+func round8(c color.Color) color.RGBA {
+	r, g, b, a := c.RGBA()
+	return color.RGBA{
+		uint8((r*0xff + 0x7fff) / 0xffff),
+		uint8((g*0xff + 0x7fff) / 0xffff),
+		uint8((b*0xff + 0x7fff) / 0xffff),
+		uint8((a*0xff + 0x7fff) / 0xffff),
+	}
+}
+
+// is16Bit reports whether m stores 16 bits per channel.
+func is16Bit(m image.Image) bool {
+	switch m.ColorModel() {
+	case color.RGBA64Model, color.NRGBA64Model, color.Gray16Model, color.Alpha16Model:
+		return true
+	}
+	return false
+}
+
+// clamp8 rounds f to the nearest uint8, clamping it to [0, 0xff]. It is a
+// copy of the one in package interp, which is unexported there so as not to
+// add a general numeric helper to the interpolation API; the two must round
+// alike, so that the fast paths here match the interpolators.
+func clamp8(f float64) uint8 {
+	if f <= 0 {
+		return 0
+	}
+	if f >= 0xff {
+		return 0xff
+	}
+	return uint8(f + 0.5)
+}
@@ -6,30 +6,151 @@ package graphics
 
 import (
 	"github.com/image-server/graphics-go/graphics/interp"
-	"errors"
 	"image"
 	"image/draw"
+	"math"
 )
 
 // RotateOptions are the rotation parameters.
 // Angle is the angle, in radians, to rotate the image clockwise.
+// ThreeShear selects the three-shear rotation, which turns the image by
+// whole quarter turns exactly and decomposes the rest of the rotation into
+// three shears, each a one dimensional resampling. It blurs less than the
+// default bilinear rotation, but writes every pixel of dst, making those
+// outside the rotated image transparent.
 type RotateOptions struct {
-	Angle float64
+	Angle      float64
+	ThreeShear bool
 }
 
 // Rotate produces a rotated version of src, drawn onto dst.
 func Rotate(dst draw.Image, src image.Image, opt *RotateOptions) error {
 	if dst == nil {
-		return errors.New("graphics: dst is nil")
+		return ErrNilDst
 	}
 	if src == nil {
-		return errors.New("graphics: src is nil")
+		return ErrNilSrc
 	}
 
 	angle := 0.0
 	if opt != nil {
 		angle = opt.Angle
+		if opt.ThreeShear {
+			if !dst.Bounds().Empty() && !src.Bounds().Empty() {
+				rotateShear(dst, src, angle)
+			}
+			return nil
+		}
 	}
 
 	return I.Rotate(angle).TransformCenter(dst, src, interp.Bilinear)
 }
+
+// RotateAbout returns src rotated clockwise by angle, in radians, about the
+// pivot (px, py) in src's co-ordinates, using bilinear interpolation. The
+// result is in the same co-ordinate space as src, so the pivot stays put.
+// If expand is false, the result has the bounds of src and any content
+// rotated outside them is clipped; otherwise it is sized to exactly contain
+// the rotated image.
+func RotateAbout(src image.Image, angle, px, py float64, expand bool) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	a := I.Rotate(angle).Center(px, py)
+	b := src.Bounds()
+	if expand {
+		var err error
+		if b, err = a.bounds(b); err != nil {
+			return nil, err
+		}
+	}
+	dst := image.NewRGBA(b)
+	if err := a.Transform(dst, src, interp.Bilinear); err != nil {
+		return nil, err
+	}
+	return dst, nil
+}
+
+// RotateFrames returns n frames of src rotated clockwise about its center
+// by evenly spaced angles, frame i by i/n of a full turn, using bilinear
+// interpolation. All the frames have the same bounds, at the origin, so
+// that a sprite sheet can index them uniformly. If expand is false, they
+// have the size of src and any content rotated outside it is clipped;
+// otherwise they are the smallest size that contains every rotation, with
+// the parity of src so that the unrotated frame is not resampled. It
+// returns nil if src is nil, n is not positive or src cannot be rotated.
+func RotateFrames(src image.Image, n int, expand bool) []*image.RGBA {
+	if src == nil || n <= 0 {
+		return nil
+	}
+	sb := src.Bounds()
+	w, h := sb.Dx(), sb.Dy()
+	if expand && !sb.Empty() {
+		for i := 0; i < n; i++ {
+			b, err := I.Rotate(2 * math.Pi * float64(i) / float64(n)).bounds(sb)
+			if err != nil {
+				return nil
+			}
+			if b.Dx() > w {
+				w = b.Dx() + (b.Dx()-sb.Dx())%2
+			}
+			if b.Dy() > h {
+				h = b.Dy() + (b.Dy()-sb.Dy())%2
+			}
+		}
+	}
+	frames := make([]*image.RGBA, n)
+	for i := range frames {
+		frames[i] = image.NewRGBA(image.Rect(0, 0, w, h))
+		if sb.Empty() {
+			continue
+		}
+		a := I.Rotate(2 * math.Pi * float64(i) / float64(n))
+		if err := a.TransformCenter(frames[i], src, interp.Bilinear); err != nil {
+			return nil
+		}
+	}
+	return frames
+}
+
+// StraightenCrop rotates src clockwise by angle, in radians, about its
+// center, and crops the result to the largest centered rectangle with the
+// aspect ratio of src that lies wholly within the rotated image, so it has
+// no empty corners. The result is at the origin. It returns nil if src is
+// nil or cannot be rotated.
+func StraightenCrop(src image.Image, angle float64) *image.RGBA {
+	if src == nil {
+		return nil
+	}
+	b := src.Bounds()
+	if b.Empty() {
+		return image.NewRGBA(image.Rectangle{})
+	}
+	w, h := float64(b.Dx()), float64(b.Dy())
+
+	// The corners (±k*w/2, ±k*h/2) of the crop, turned back by angle,
+	// must lie within the half extents (w/2, h/2) of src:
+	//	k*w*|cos| + k*h*|sin| <= w
+	//	k*w*|sin| + k*h*|cos| <= h
+	sin, cos := math.Sincos(angle)
+	sin, cos = math.Abs(sin), math.Abs(cos)
+	k := math.Min(w/(w*cos+h*sin), h/(w*sin+h*cos))
+
+	// Give the crop the same parity as src so that it is exactly centered,
+	// keeping its corner pixel centers inside the rotated image.
+	size := func(n int, f float64) int {
+		m := int(f)
+		if (n-m)%2 != 0 {
+			m--
+		}
+		return max0(m)
+	}
+	cw, ch := size(b.Dx(), k*w), size(b.Dy(), k*h)
+
+	rotated := image.NewRGBA(b)
+	if err := I.Rotate(angle).TransformCenter(rotated, src, interp.Bilinear); err != nil {
+		return nil
+	}
+	x0, y0 := b.Min.X+(b.Dx()-cw)/2, b.Min.Y+(b.Dy()-ch)/2
+	return crop(rotated, image.Rect(x0, y0, x0+cw, y0+ch))
+}
@@ -7,6 +7,7 @@ package graphics
 import (
 	"github.com/image-server/graphics-go/graphics/graphicstest"
 	"image"
+	"image/color"
 	"math"
 	"testing"
 
@@ -16,25 +17,25 @@ import (
 var rotateOneColorTests = []transformOneColorTest{
 	{
 		"onepixel-onequarter", 1, 1, 1, 1,
-		&RotateOptions{math.Pi / 2},
+		&RotateOptions{Angle: math.Pi / 2},
 		[]uint8{0xff},
 		[]uint8{0xff},
 	},
 	{
 		"onepixel-partial", 1, 1, 1, 1,
-		&RotateOptions{math.Pi * 2.0 / 3.0},
+		&RotateOptions{Angle: math.Pi * 2.0 / 3.0},
 		[]uint8{0xff},
 		[]uint8{0xff},
 	},
 	{
 		"onepixel-complete", 1, 1, 1, 1,
-		&RotateOptions{2 * math.Pi},
+		&RotateOptions{Angle: 2 * math.Pi},
 		[]uint8{0xff},
 		[]uint8{0xff},
 	},
 	{
 		"even-onequarter", 2, 2, 2, 2,
-		&RotateOptions{math.Pi / 2.0},
+		&RotateOptions{Angle: math.Pi / 2.0},
 		[]uint8{
 			0xff, 0x00,
 			0x00, 0xff,
@@ -46,7 +47,7 @@ var rotateOneColorTests = []transformOneColorTest{
 	},
 	{
 		"even-complete", 2, 2, 2, 2,
-		&RotateOptions{2.0 * math.Pi},
+		&RotateOptions{Angle: 2.0 * math.Pi},
 		[]uint8{
 			0xff, 0x00,
 			0x00, 0xff,
@@ -58,7 +59,7 @@ var rotateOneColorTests = []transformOneColorTest{
 	},
 	{
 		"line-partial", 3, 3, 3, 3,
-		&RotateOptions{math.Pi * 1.0 / 3.0},
+		&RotateOptions{Angle: math.Pi * 1.0 / 3.0},
 		[]uint8{
 			0x00, 0x00, 0x00,
 			0xff, 0xff, 0xff,
@@ -72,7 +73,7 @@ var rotateOneColorTests = []transformOneColorTest{
 	},
 	{
 		"line-offset-partial", 3, 3, 3, 3,
-		&RotateOptions{math.Pi * 3 / 2},
+		&RotateOptions{Angle: math.Pi * 3 / 2},
 		[]uint8{
 			0x00, 0x00, 0x00,
 			0x00, 0xff, 0xff,
@@ -86,7 +87,7 @@ var rotateOneColorTests = []transformOneColorTest{
 	},
 	{
 		"dot-partial", 4, 4, 4, 4,
-		&RotateOptions{math.Pi},
+		&RotateOptions{Angle: math.Pi},
 		[]uint8{
 			0x00, 0x00, 0x00, 0x00,
 			0x00, 0xff, 0x00, 0x00,
@@ -132,7 +133,7 @@ func TestRotateGopherSide(t *testing.T) {
 
 	srcb := src.Bounds()
 	dst := image.NewRGBA(image.Rect(0, 0, srcb.Dy(), srcb.Dx()))
-	if err := Rotate(dst, src, &RotateOptions{math.Pi / 2.0}); err != nil {
+	if err := Rotate(dst, src, &RotateOptions{Angle: math.Pi / 2.0}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -154,7 +155,7 @@ func TestRotateGopherPartial(t *testing.T) {
 
 	srcb := src.Bounds()
 	dst := image.NewRGBA(image.Rect(0, 0, srcb.Dx(), srcb.Dy()))
-	if err := Rotate(dst, src, &RotateOptions{math.Pi / 3.0}); err != nil {
+	if err := Rotate(dst, src, &RotateOptions{Angle: math.Pi / 3.0}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -167,3 +168,210 @@ func TestRotateGopherPartial(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRotateSubImage(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := image.Rect(30, 40, 130, 190)
+	view := toRGBA(src).SubImage(r)
+	copied := crop(src, r)
+
+	opt := &RotateOptions{Angle: math.Pi / 5}
+	want := image.NewRGBA(copied.Bounds())
+	if err := Rotate(want, copied, opt); err != nil {
+		t.Fatal(err)
+	}
+	got := image.NewRGBA(copied.Bounds())
+	if err := Rotate(got, view, opt); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+		t.Error(err)
+	}
+}
+
+// rotateDiff returns the mean absolute channel difference
+// between m0 and m1 over the pixels of b that a maps from well inside b.
+func rotateDiff(m0, m1 *image.RGBA, a Affine, b image.Rectangle) float64 {
+	inner := b.Inset(2)
+	var sum, n float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if sx, sy := a.pt(x, y); !inBounds(inner, sx, sy) {
+				continue
+			}
+			p, q := m0.RGBAAt(x, y), m1.RGBAAt(x, y)
+			for _, d := range []int{
+				int(p.R) - int(q.R), int(p.G) - int(q.G),
+				int(p.B) - int(q.B), int(p.A) - int(q.A),
+			} {
+				if d < 0 {
+					d = -d
+				}
+				sum += float64(d)
+				n++
+			}
+		}
+	}
+	return sum / n
+}
+
+func TestRotateThreeShear(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := src.Bounds()
+	for _, angle := range []float64{0.1, -math.Pi / 5, math.Pi / 3, 2.5, -2} {
+		want := image.NewRGBA(b)
+		if err := Rotate(want, src, &RotateOptions{Angle: angle}); err != nil {
+			t.Fatal(err)
+		}
+		got := image.NewRGBA(b)
+		if err := Rotate(got, src, &RotateOptions{Angle: angle, ThreeShear: true}); err != nil {
+			t.Fatal(err)
+		}
+		// The methods differ only by resampling error, not geometry.
+		if mean := rotateDiff(got, want, I.Rotate(angle).CenterFit(b, b), b); mean > 1 {
+			t.Errorf("angle %v: mean difference %.3f from bilinear rotation", angle, mean)
+		}
+
+		// A round trip through the three-shear rotation should lose no
+		// more detail than one through the bilinear rotation.
+		var errs [2]float64
+		for i, shear := range []bool{false, true} {
+			m := image.NewRGBA(b)
+			back := image.NewRGBA(b)
+			if err := Rotate(m, src, &RotateOptions{Angle: angle, ThreeShear: shear}); err != nil {
+				t.Fatal(err)
+			}
+			if err := Rotate(back, m, &RotateOptions{Angle: -angle, ThreeShear: shear}); err != nil {
+				t.Fatal(err)
+			}
+			errs[i] = rotateDiff(back, toRGBA(src), I.Rotate(angle).CenterFit(b, b), b.Inset(b.Dx()/4))
+		}
+		if errs[1] > errs[0] {
+			t.Errorf("angle %v: round trip error %.3f, bilinear %.3f", angle, errs[1], errs[0])
+		}
+	}
+}
+
+func TestRotateThreeShearQuarter(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srcb := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, srcb.Dy(), srcb.Dx()))
+	if err := Rotate(dst, src, &RotateOptions{Angle: math.Pi / 2, ThreeShear: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cmp, err := graphicstest.LoadImage("../testdata/gopher-rotate-side.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, cmp, 0x101); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRotateAbout(t *testing.T) {
+	src := newUniformRGBA(image.Rect(0, 0, 20, 10), color.RGBA{0xff, 0, 0, 0xff})
+
+	// A quarter turn about the top-left corner swings the image to the
+	// left of it, entirely outside the bounds of src.
+	m, err := RotateAbout(src, math.Pi/2, 0, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), image.Rect(-10, 0, 0, 20); !got.Eq(want) {
+		t.Fatalf("expand: got bounds %v want %v", got, want)
+	}
+	for y := 0; y < 20; y++ {
+		for x := -10; x < 0; x++ {
+			if c := m.RGBAAt(x, y); c.A != 0xff {
+				t.Fatalf("expand: (%d, %d) got %v, want opaque", x, y, c)
+			}
+		}
+	}
+
+	// Without expansion it is all clipped.
+	m, err = RotateAbout(src, math.Pi/2, 0, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Bounds(), src.Bounds(); !got.Eq(want) {
+		t.Fatalf("clip: got bounds %v want %v", got, want)
+	}
+	for i := 3; i < len(m.Pix); i += 4 {
+		if m.Pix[i] != 0 {
+			t.Fatalf("clip: got alpha 0x%02x at offset %d, want transparent", m.Pix[i], i)
+		}
+	}
+}
+
+func TestRotateFrames(t *testing.T) {
+	src := NewNoise(6, 4, 1)
+	frames := RotateFrames(src, 4, true)
+	if len(frames) != 4 {
+		t.Fatalf("got %d frames want 4", len(frames))
+	}
+	want := image.Rect(0, 0, 6, 6)
+	for i, m := range frames {
+		if !m.Bounds().Eq(want) {
+			t.Fatalf("frame %d: got bounds %v want %v", i, m.Bounds(), want)
+		}
+		// The quarter turned src, centered in the frame.
+		q := rotateQuarters(src, i)
+		r := q.Bounds().Add(image.Pt((6-q.Bounds().Dx())/2, (6-q.Bounds().Dy())/2))
+		if err := graphicstest.ImageWithinTolerance(crop(m, r), q, 1); err != nil {
+			t.Errorf("frame %d: %v", i, err)
+		}
+	}
+
+	// Without expansion, the frames have the size of src.
+	for i, m := range RotateFrames(src, 3, false) {
+		if !m.Bounds().Eq(image.Rect(0, 0, 6, 4)) {
+			t.Errorf("clipped frame %d: got bounds %v", i, m.Bounds())
+		}
+	}
+	if got := RotateFrames(src, 0, true); got != nil {
+		t.Errorf("n = 0: got %d frames want none", len(got))
+	}
+	if got := RotateFrames(nil, 4, true); got != nil {
+		t.Errorf("nil src: got %d frames want none", len(got))
+	}
+}
+
+func TestStraightenCrop(t *testing.T) {
+	src := NewNoise(200, 120, 1)
+	for _, deg := range []float64{5, -5, 30} {
+		m := StraightenCrop(src, deg*math.Pi/180)
+		b := m.Bounds()
+		// The crop keeps the aspect ratio and, for a small angle, most
+		// of the image.
+		if r := float64(b.Dx()) / float64(b.Dy()); math.Abs(r-200.0/120) > 0.05 {
+			t.Errorf("%v°: got %v, aspect ratio %.3f", deg, b, r)
+		}
+		if deg == 5 && b.Dx() < 160 {
+			t.Errorf("%v°: got %v, want at least 160 wide", deg, b)
+		}
+		for i := 3; i < len(m.Pix); i += 4 {
+			if m.Pix[i] != 0xff {
+				t.Fatalf("%v°: got alpha 0x%02x at offset %d, want opaque", deg, m.Pix[i], i)
+			}
+		}
+	}
+
+	if m := StraightenCrop(nil, 0.1); m != nil {
+		t.Errorf("nil src: got %v want nil", m.Bounds())
+	}
+	if m := StraightenCrop(src, math.NaN()); m != nil {
+		t.Errorf("NaN angle: got %v want nil", m.Bounds())
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+)
+
+// SaveJPEG encodes img as a JPEG of the given quality, from 1 to 100, and
+// writes it to the file at path, creating or truncating it.
+func SaveJPEG(img image.Image, path string, quality int) error {
+	return save(path, func(w io.Writer) error {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	})
+}
+
+// SaveJPEGWithMetadata is like SaveJPEG, but embeds the ICC profile and
+// EXIF data of md, as returned by LoadWithOptions. A nil md is equivalent
+// to SaveJPEG.
+func SaveJPEGWithMetadata(img image.Image, path string, quality int, md *Metadata) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	return save(path, func(w io.Writer) error {
+		_, err := w.Write(jpegWithMetadata(buf.Bytes(), md))
+		return err
+	})
+}
+
+// EncodeJPEGTargetSize encodes img as a JPEG of the highest quality, from 1
+// to 100, whose encoding is at most maxBytes long, and returns the
+// encoding and that quality. It binary searches the quality, relying on
+// the size growing with it. It returns an error if even quality 1 is too
+// large.
+func EncodeJPEGTargetSize(img image.Image, maxBytes int) ([]byte, int, error) {
+	encode := func(q int) ([]byte, error) {
+		var buf bytes.Buffer
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: q})
+		return buf.Bytes(), err
+	}
+	// Search for the best quality in [lo, hi] that fits, where best is
+	// the encoding at quality lo-1, if any.
+	var best []byte
+	lo, hi := 1, 100
+	for lo <= hi {
+		q := (lo + hi) / 2
+		b, err := encode(q)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(b) <= maxBytes {
+			best, lo = b, q+1
+		} else {
+			hi = q - 1
+		}
+	}
+	if best == nil {
+		return nil, 0, errors.New("graphics: image does not fit in the size budget")
+	}
+	return best, lo - 1, nil
+}
+
+// SavePNG encodes img as a PNG and writes it to the file at path, creating
+// or truncating it.
+func SavePNG(img image.Image, path string) error {
+	return save(path, func(w io.Writer) error {
+		return png.Encode(w, img)
+	})
+}
+
+// SaveGIF encodes img as a GIF and writes it to the file at path, creating
+// or truncating it. If opt is nil, the GIF encoder's defaults are used.
+func SaveGIF(img image.Image, path string, opt *gif.Options) error {
+	return save(path, func(w io.Writer) error {
+		return gif.Encode(w, img, opt)
+	})
+}
+
+func save(path string, encode func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := encode(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
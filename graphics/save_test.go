@@ -0,0 +1,89 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"bytes"
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/jpeg"
+	"path/filepath"
+	"testing"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	src := NewNoise(23, 17, 1)
+
+	tests := []struct {
+		name string
+		save func(path string) error
+	}{
+		{"out.jpg", func(path string) error { return SaveJPEG(src, path, 90) }},
+		{"out.png", func(path string) error { return SavePNG(src, path) }},
+		{"out.gif", func(path string) error { return SaveGIF(src, path, nil) }},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		// Saving twice checks that an existing file is truncated.
+		for i := 0; i < 2; i++ {
+			if err := tt.save(path); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		m, err := graphicstest.LoadImage(path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, want := m.Bounds(), image.Rect(0, 0, 23, 17); !got.Eq(want) {
+			t.Errorf("%s: got bounds %v want %v", tt.name, got, want)
+		}
+	}
+
+	if err := SavePNG(src, filepath.Join(dir, "missing", "out.png")); err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestEncodeJPEGTargetSize(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := func(q int) int {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, src, &jpeg.Options{Quality: q}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Len()
+	}
+	// A budget between the sizes at qualities 70 and 71 selects 70.
+	budget := (size(70) + size(71)) / 2
+	b, q, err := EncodeJPEGTargetSize(src, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) > budget {
+		t.Errorf("got %d bytes, over the budget of %d", len(b), budget)
+	}
+	if q != 70 {
+		t.Errorf("got quality %d want 70", q)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(b)); err != nil {
+		t.Error(err)
+	}
+
+	// Everything fits a generous budget.
+	if _, q, err := EncodeJPEGTargetSize(src, 1<<30); err != nil || q != 100 {
+		t.Errorf("large budget: got quality %d, %v, want 100", q, err)
+	}
+	if _, _, err := EncodeJPEGTargetSize(src, 100); err == nil {
+		t.Error("tiny budget: expected error")
+	}
+}
@@ -6,18 +6,21 @@ package graphics
 
 import (
 	"github.com/image-server/graphics-go/graphics/interp"
-	"errors"
 	"image"
 	"image/draw"
 )
 
 // Scale produces a scaled version of the image using bilinear interpolation.
 func Scale(dst draw.Image, src image.Image) error {
+	return scale(dst, src, nil)
+}
+
+func scale(dst draw.Image, src image.Image, cancel <-chan struct{}) error {
 	if dst == nil {
-		return errors.New("graphics: dst is nil")
+		return ErrNilDst
 	}
 	if src == nil {
-		return errors.New("graphics: src is nil")
+		return ErrNilSrc
 	}
 
 	b := dst.Bounds()
@@ -27,5 +30,9 @@ func Scale(dst draw.Image, src image.Image) error {
 	}
 	sx := float64(b.Dx()) / float64(srcb.Dx())
 	sy := float64(b.Dy()) / float64(srcb.Dy())
-	return I.Scale(sx, sy).Transform(dst, src, interp.Bilinear)
+	// Map dst's origin to src's, so neither need start at (0, 0).
+	a := I.Translate(-float64(srcb.Min.X), -float64(srcb.Min.Y)).
+		Scale(sx, sy).
+		Translate(float64(b.Min.X), float64(b.Min.Y))
+	return a.transform(dst, src, interp.Bilinear, cancel)
 }
@@ -151,3 +151,36 @@ func TestScaleGopher(t *testing.T) {
 		return
 	}
 }
+
+func TestScaleSubImage(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A view of the middle of src, and the same pixels copied to the origin.
+	r := image.Rect(30, 40, 130, 190)
+	view := toRGBA(src).SubImage(r)
+	copied := crop(src, r)
+
+	want := image.NewRGBA(image.Rect(0, 0, 50, 75))
+	if err := Scale(want, copied); err != nil {
+		t.Fatal(err)
+	}
+	got := image.NewRGBA(image.Rect(0, 0, 50, 75))
+	if err := Scale(got, view); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+		t.Error(err)
+	}
+
+	// The same holds for a dst that does not start at the origin.
+	offset := image.NewRGBA(image.Rect(7, 9, 57, 84))
+	if err := Scale(offset, view); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(crop(offset, offset.Bounds()), want, 0); err != nil {
+		t.Error(err)
+	}
+}
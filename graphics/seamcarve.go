@@ -0,0 +1,239 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// seamImage is a compact, origin-based pixel buffer that seams can be
+// removed from and inserted into.
+type seamImage struct {
+	w, h int
+	pix  []color.RGBA
+	// idx maps each pixel to the column it occupied in the original image.
+	idx []int
+}
+
+func newSeamImage(src *image.RGBA) *seamImage {
+	b := src.Bounds()
+	m := &seamImage{
+		w:   b.Dx(),
+		h:   b.Dy(),
+		pix: make([]color.RGBA, b.Dx()*b.Dy()),
+		idx: make([]int, b.Dx()*b.Dy()),
+	}
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			off := y*src.Stride + x*4
+			p := src.Pix[off : off+4]
+			m.pix[y*m.w+x] = color.RGBA{p[0], p[1], p[2], p[3]}
+			m.idx[y*m.w+x] = x
+		}
+	}
+	return m
+}
+
+func (m *seamImage) transpose() *seamImage {
+	t := &seamImage{
+		w:   m.h,
+		h:   m.w,
+		pix: make([]color.RGBA, len(m.pix)),
+		idx: make([]int, len(m.idx)),
+	}
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			t.pix[x*t.w+y] = m.pix[y*m.w+x]
+			t.idx[x*t.w+y] = m.idx[y*m.w+x]
+		}
+	}
+	return t
+}
+
+func (m *seamImage) resetIdx() {
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			m.idx[y*m.w+x] = x
+		}
+	}
+}
+
+// energy returns the Sobel gradient magnitude of every pixel.
+func (m *seamImage) energy() []float64 {
+	lum := make([]float64, len(m.pix))
+	for i, c := range m.pix {
+		lum[i] = luma(c.R, c.G, c.B)
+	}
+	gx, gy := sobel(lum, m.w, m.h)
+	e := make([]float64, len(lum))
+	for i := range e {
+		e[i] = math.Abs(gx[i]) + math.Abs(gy[i])
+	}
+	return e
+}
+
+// seam returns the x co-ordinate, per row, of the vertical seam with the
+// lowest cumulative energy. Ties are broken towards the left.
+func (m *seamImage) seam() []int {
+	e := m.energy()
+	w, h := m.w, m.h
+	for y := 1; y < h; y++ {
+		for x := 0; x < w; x++ {
+			best := e[(y-1)*w+x]
+			if x > 0 && e[(y-1)*w+x-1] <= best {
+				best = e[(y-1)*w+x-1]
+			}
+			if x < w-1 && e[(y-1)*w+x+1] < best {
+				best = e[(y-1)*w+x+1]
+			}
+			e[y*w+x] += best
+		}
+	}
+
+	s := make([]int, h)
+	for x := 1; x < w; x++ {
+		if e[(h-1)*w+x] < e[(h-1)*w+s[h-1]] {
+			s[h-1] = x
+		}
+	}
+	for y := h - 2; y >= 0; y-- {
+		x := s[y+1]
+		best := x
+		if x > 0 && e[y*w+x-1] <= e[y*w+best] {
+			best = x - 1
+		}
+		if x < w-1 && e[y*w+x+1] < e[y*w+best] {
+			best = x + 1
+		}
+		s[y] = best
+	}
+	return s
+}
+
+// removeSeam removes the pixels of seam s, reducing the width by one.
+func (m *seamImage) removeSeam(s []int) {
+	w := m.w - 1
+	pix := make([]color.RGBA, w*m.h)
+	idx := make([]int, w*m.h)
+	for y := 0; y < m.h; y++ {
+		copy(pix[y*w:], m.pix[y*m.w:y*m.w+s[y]])
+		copy(pix[y*w+s[y]:], m.pix[y*m.w+s[y]+1:(y+1)*m.w])
+		copy(idx[y*w:], m.idx[y*m.w:y*m.w+s[y]])
+		copy(idx[y*w+s[y]:], m.idx[y*m.w+s[y]+1:(y+1)*m.w])
+	}
+	m.w, m.pix, m.idx = w, pix, idx
+}
+
+// shrink removes n vertical seams.
+func (m *seamImage) shrink(n int) {
+	for i := 0; i < n; i++ {
+		m.removeSeam(m.seam())
+	}
+}
+
+// grow inserts n vertical seams. The n lowest energy seams are found by
+// removing them from a copy, then each is duplicated in m by averaging it
+// with its right-hand neighbour.
+func (m *seamImage) grow(n int) {
+	c := &seamImage{
+		w:   m.w,
+		h:   m.h,
+		pix: append([]color.RGBA(nil), m.pix...),
+		idx: append([]int(nil), m.idx...),
+	}
+	c.resetIdx()
+	dup := make([][]bool, m.h)
+	for y := range dup {
+		dup[y] = make([]bool, m.w)
+	}
+	for i := 0; i < n && c.w > 0; i++ {
+		s := c.seam()
+		for y, x := range s {
+			dup[y][c.idx[y*c.w+x]] = true
+		}
+		c.removeSeam(s)
+	}
+
+	count := 0
+	for _, d := range dup[0] {
+		if d {
+			count++
+		}
+	}
+	w := m.w + count
+	pix := make([]color.RGBA, 0, w*m.h)
+	idx := make([]int, 0, w*m.h)
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			p := m.pix[y*m.w+x]
+			pix = append(pix, p)
+			idx = append(idx, m.idx[y*m.w+x])
+			if dup[y][x] {
+				q := p
+				if x+1 < m.w {
+					q = m.pix[y*m.w+x+1]
+				}
+				pix = append(pix, color.RGBA{
+					uint8((int(p.R) + int(q.R) + 1) / 2),
+					uint8((int(p.G) + int(q.G) + 1) / 2),
+					uint8((int(p.B) + int(q.B) + 1) / 2),
+					uint8((int(p.A) + int(q.A) + 1) / 2),
+				})
+				idx = append(idx, m.idx[y*m.w+x])
+			}
+		}
+	}
+	m.w, m.pix, m.idx = w, pix, idx
+}
+
+// resize carves or inserts vertical seams until the width is w. Each call
+// to grow at most doubles the width.
+func (m *seamImage) resize(w int) {
+	if w < m.w {
+		m.shrink(m.w - w)
+	}
+	for w > m.w {
+		n := w - m.w
+		if n > m.w {
+			n = m.w
+		}
+		m.grow(n)
+	}
+}
+
+// SeamCarve resizes src to newW by newH using content-aware seam carving.
+// Seams of low Sobel energy are removed (or duplicated, when growing) so
+// that high detail regions keep their proportions. The width is adjusted
+// first, then the height.
+func SeamCarve(src image.Image, newW, newH int) (*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	if newW <= 0 || newH <= 0 {
+		return nil, errors.New("graphics: invalid seam carve size")
+	}
+	sb := src.Bounds()
+	if sb.Empty() {
+		return nil, ErrEmptySrc
+	}
+
+	m := newSeamImage(toRGBA(src))
+	m.resize(newW)
+	m = m.transpose()
+	m.resetIdx()
+	m.resize(newH)
+	m = m.transpose()
+
+	dst := image.NewRGBA(image.Rect(0, 0, m.w, m.h))
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			dst.SetRGBA(x, y, m.pix[y*m.w+x])
+		}
+	}
+	return dst, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newSubjectImage returns a flat gray image with a steep diagonal ramp, a
+// high energy subject, occupying columns [x0, x0+sw).
+func newSubjectImage(w, h, x0, sw int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0x80, 0x80, 0x80, 0xff}
+			if x >= x0 && x < x0+sw {
+				v := uint8(0x10 + (x-x0)*0x30 + y*0x05)
+				if v == 0x80 {
+					v++
+				}
+				c = color.RGBA{v, v, v, 0xff}
+			}
+			m.SetRGBA(x, y, c)
+		}
+	}
+	return m
+}
+
+// subjectWidth returns the number of columns that contain any non-gray pixel.
+func subjectWidth(m *image.RGBA) int {
+	b := m.Bounds()
+	n := 0
+	for x := b.Min.X; x < b.Max.X; x++ {
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			if m.RGBAAt(x, y).R != 0x80 {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+func TestSeamCarveShrink(t *testing.T) {
+	src := newSubjectImage(40, 20, 15, 10)
+	dst, err := SeamCarve(src, 30, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dst.Bounds(), image.Rect(0, 0, 30, 20); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	if got := subjectWidth(dst); got != 10 {
+		t.Errorf("subject width: got %d want 10", got)
+	}
+}
+
+func TestSeamCarveGrow(t *testing.T) {
+	src := newSubjectImage(20, 10, 5, 6)
+	dst, err := SeamCarve(src, 30, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dst.Bounds(), image.Rect(0, 0, 30, 15); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	if got := subjectWidth(dst); got != 6 {
+		t.Errorf("subject width: got %d want 6", got)
+	}
+}
+
+func TestSeamCarveGrowSinglePixel(t *testing.T) {
+	// A single column or row can only be duplicated.
+	c := color.RGBA{0x20, 0x40, 0x60, 0xff}
+	for _, tt := range []struct {
+		src        image.Rectangle
+		newW, newH int
+	}{
+		{image.Rect(0, 0, 1, 5), 3, 5},
+		{image.Rect(0, 0, 5, 1), 5, 4},
+		{image.Rect(0, 0, 1, 1), 4, 3},
+	} {
+		dst, err := SeamCarve(newUniformRGBA(tt.src, c), tt.newW, tt.newH)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := newUniformRGBA(image.Rect(0, 0, tt.newW, tt.newH), c)
+		if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+			t.Errorf("%v to %dx%d: %v", tt.src, tt.newW, tt.newH, err)
+		}
+	}
+}
+
+func TestSeamCarveInvalid(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if _, err := SeamCarve(src, 0, 4); err == nil {
+		t.Error("expected error for zero width")
+	}
+	if _, err := SeamCarve(nil, 4, 4); err == nil {
+		t.Error("expected error for nil src")
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// DropShadow returns src composited over a shadow: a silhouette of its
+// alpha in shadowColor, moved by offset and blurred with a standard
+// deviation of blur, as for a screenshot or mockup. The result is at the
+// origin, and is larger than src by just enough to hold the shadow out to
+// three deviations, with src placed so that the shadow keeps its offset
+// from it. The rest of the canvas is transparent. A negative blur is
+// treated as zero, which gives a hard shadow.
+func DropShadow(src image.Image, offset image.Point, blur float64, shadowColor color.Color) *image.RGBA {
+	if src == nil {
+		return nil
+	}
+	blur = math.Max(0, blur)
+	sb := src.Bounds()
+	m := int(math.Ceil(3 * blur))
+	sr := sb.Add(offset).Inset(-m)
+	u := sb.Union(sr)
+	r := image.Rect(0, 0, u.Dx(), u.Dy())
+
+	// Fill the shadow color through the alpha of src.
+	sil := image.NewRGBA(r)
+	draw.DrawMask(sil, sb.Add(offset).Sub(u.Min), image.NewUniform(shadowColor), image.ZP, src, sb.Min, draw.Src)
+	dst := image.NewRGBA(r)
+	if err := Blur(dst, sil, &BlurOptions{StdDev: blur, Edge: BlurZero}); err != nil {
+		return nil
+	}
+	draw.Draw(dst, sb.Sub(u.Min), src, sb.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,64 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDropShadow(t *testing.T) {
+	// An opaque red square in the middle of a transparent image.
+	src := image.NewRGBA(image.Rect(10, 10, 30, 30))
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	for y := 15; y < 25; y++ {
+		for x := 15; x < 25; x++ {
+			src.SetRGBA(x, y, red)
+		}
+	}
+	shadow := color.RGBA{0x00, 0x00, 0x00, 0x80}
+
+	m := DropShadow(src, image.Pt(4, 6), 2, shadow)
+	// The shadow reaches 4+6 right and 6+6 down, and 6-4 left, of src.
+	if got, want := m.Bounds(), image.Rect(0, 0, 2+20+10, 20+12); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	// src is at (2, 0), so the square covers [7, 17) by [5, 15), and the
+	// core of its shadow [11, 21) by [11, 21).
+	for _, pt := range []image.Point{{7, 5}, {12, 10}, {16, 14}} {
+		if got := m.RGBAAt(pt.X, pt.Y); got != red {
+			t.Errorf("square at %v: got %v want %v", pt, got, red)
+		}
+	}
+	core := m.RGBAAt(16, 16)
+	if d := int(core.A) - int(shadow.A); d < -4 || d > 4 || core.R != 0 {
+		t.Errorf("shadow core: got %v want about %v", core, shadow)
+	}
+	// The shadow is soft: it fades across its edge, rather than stopping.
+	inside, edge, outside := m.RGBAAt(16, 19).A, m.RGBAAt(16, 21).A, m.RGBAAt(16, 23).A
+	if !(core.A >= inside && inside > edge && edge > outside && outside > 0) {
+		t.Errorf("shadow edge: got alphas %d, %d, %d, %d, want decreasing from the core",
+			core.A, inside, edge, outside)
+	}
+	// Away from both, the canvas is transparent.
+	for _, pt := range []image.Point{{0, 0}, {31, 0}, {0, 31}, {31, 31}} {
+		if got := m.RGBAAt(pt.X, pt.Y); got.A != 0 {
+			t.Errorf("corner %v: got %v want transparent", pt, got)
+		}
+	}
+
+	// Without blur, the shadow is a hard copy of the silhouette.
+	m = DropShadow(src, image.Pt(3, 3), 0, shadow)
+	if got, want := m.Bounds(), image.Rect(0, 0, 23, 23); !got.Eq(want) {
+		t.Fatalf("hard: got bounds %v want %v", got, want)
+	}
+	if got := m.RGBAAt(16, 16); got != shadow {
+		t.Errorf("hard shadow: got %v want %v", got, shadow)
+	}
+	if got := m.RGBAAt(18, 18); got.A != 0 {
+		t.Errorf("past hard shadow: got %v want transparent", got)
+	}
+}
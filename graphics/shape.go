@@ -0,0 +1,77 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// shapeSamples is the number of sub-pixel samples per axis used to compute
+// the anti-aliased coverage of a pixel.
+const shapeSamples = 4
+
+// DrawCircle draws a circle of the given radius onto dst, centered on the
+// center pixel, with anti-aliased edges. If fill is false, only a one pixel
+// wide outline is drawn. The circle is composited over dst's existing
+// pixels.
+func DrawCircle(dst draw.Image, center image.Point, radius float64, c color.Color, fill bool) {
+	DrawEllipse(dst, center, radius, radius, c, fill)
+}
+
+// DrawEllipse draws an axis-aligned ellipse with the horizontal and vertical
+// radii rx and ry onto dst, like DrawCircle.
+func DrawEllipse(dst draw.Image, center image.Point, rx, ry float64, c color.Color, fill bool) {
+	if rx <= 0 || ry <= 0 {
+		return
+	}
+	cx, cy := float64(center.X)+0.5, float64(center.Y)+0.5
+	pad := 1.0
+	r := image.Rect(
+		int(math.Floor(cx-rx-pad)), int(math.Floor(cy-ry-pad)),
+		int(math.Ceil(cx+rx+pad)), int(math.Ceil(cy+ry+pad)),
+	).Intersect(dst.Bounds())
+	if r.Empty() {
+		return
+	}
+
+	// inside reports whether the point (x, y) is covered, approximating the
+	// distance to the ellipse's edge by scaling the implicit function by
+	// the length of its gradient.
+	inside := func(x, y float64) bool {
+		dx, dy := (x-cx)/rx, (y-cy)/ry
+		f := math.Sqrt(dx*dx + dy*dy)
+		if f == 0 {
+			return fill
+		}
+		g := math.Sqrt(dx*dx/(rx*rx)+dy*dy/(ry*ry)) / f
+		d := (f - 1) / g
+		if fill {
+			return d <= 0
+		}
+		return d >= -0.5 && d <= 0.5
+	}
+
+	mask := image.NewAlpha(r)
+	const n = shapeSamples
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			hits := 0
+			for sy := 0; sy < n; sy++ {
+				for sx := 0; sx < n; sx++ {
+					px := float64(x) + (float64(sx)+0.5)/n
+					py := float64(y) + (float64(sy)+0.5)/n
+					if inside(px, py) {
+						hits++
+					}
+				}
+			}
+			mask.Pix[(y-r.Min.Y)*mask.Stride+x-r.Min.X] = uint8((hits*0xff + n*n/2) / (n * n))
+		}
+	}
+	draw.DrawMask(dst, r, image.NewUniform(c), image.ZP, mask, r.Min, draw.Over)
+}
@@ -0,0 +1,70 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDrawCircle(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red := color.RGBA{0xff, 0x00, 0x00, 0xff}
+	dst := newUniformRGBA(image.Rect(0, 0, 40, 40), white)
+	DrawCircle(dst, image.Pt(20, 20), 10, red, true)
+
+	if got := dst.RGBAAt(20, 20); got != red {
+		t.Errorf("center: got %v want %v", got, red)
+	}
+	if got := dst.RGBAAt(20, 12); got != red {
+		t.Errorf("interior: got %v want %v", got, red)
+	}
+	for _, pt := range []image.Point{{0, 0}, {20, 5}, {8, 8}, {39, 20}} {
+		if got := dst.RGBAAt(pt.X, pt.Y); got != white {
+			t.Errorf("outside %v: got %v want %v", pt, got, white)
+		}
+	}
+	// The pixel straddling the edge diagonally is partially covered.
+	edge := dst.RGBAAt(27, 27)
+	if edge.G == 0x00 || edge.G == 0xff {
+		t.Errorf("edge: got %v want partial coverage", edge)
+	}
+}
+
+func TestDrawCircleOutline(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	c := color.RGBA{0, 0, 0xff, 0xff}
+	DrawCircle(dst, image.Pt(20, 20), 10, c, false)
+
+	if got := dst.RGBAAt(20, 20); got.A != 0 {
+		t.Errorf("center: got %v want untouched", got)
+	}
+	if got := dst.RGBAAt(20, 10); got.A < 0x80 {
+		t.Errorf("outline: got %v want mostly covered", got)
+	}
+}
+
+func TestDrawEllipse(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	c := color.RGBA{0, 0x80, 0, 0xff}
+	DrawEllipse(dst, image.Pt(20, 10), 15, 5, c, true)
+
+	if got := dst.RGBAAt(32, 10); got != c {
+		t.Errorf("inside major axis: got %v want %v", got, c)
+	}
+	if got := dst.RGBAAt(20, 17); got.A != 0 {
+		t.Errorf("outside minor axis: got %v want untouched", got)
+	}
+	partial := 0
+	for x := 0; x < 40; x++ {
+		if a := dst.RGBAAt(x, 10).A; a > 0 && a < 0xff {
+			partial++
+		}
+	}
+	if partial == 0 {
+		t.Error("no partially covered pixels along the major axis")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/image-server/graphics-go/graphics/graphicstest"
 	"image"
 	"image/color"
+	"image/draw"
 	"testing"
 )
 
@@ -67,3 +68,34 @@ func checkTransformTest(t *testing.T, oc *transformOneColorTest, dst *image.RGBA
 
 	return true
 }
+
+// grayGopher returns the gopher test image in gray, both as an *image.Gray
+// and as an opaque *image.RGBA.
+func grayGopher(t *testing.T) (*image.Gray, *image.RGBA) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := src.Bounds()
+	g := image.NewGray(b)
+	draw.Draw(g, b, src, b.Min, draw.Src)
+	m := image.NewRGBA(b)
+	draw.Draw(m, b, g, b.Min, draw.Src)
+	return g, m
+}
+
+// checkGrayParity checks that g is within tol of the red channel of m.
+func checkGrayParity(t *testing.T, desc string, g *image.Gray, m *image.RGBA, tol int) {
+	b := g.Bounds()
+	if !b.Eq(m.Bounds()) {
+		t.Fatalf("%s: got bounds %v want %v", desc, b, m.Bounds())
+	}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			d := int(g.GrayAt(x, y).Y) - int(m.RGBAAt(x, y).R)
+			if d < -tol || d > tol {
+				t.Fatalf("%s: (%d, %d) got %d want %d", desc, x, y, g.GrayAt(x, y).Y, m.RGBAAt(x, y).R)
+			}
+		}
+	}
+}
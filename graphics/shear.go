@@ -0,0 +1,188 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// shearPlane is a premultiplied RGBA image with float64 channels, used for
+// the intermediate results of rotateShear. Its coordinate system is
+// centered on the rotation: pixel (i, j) has its center at
+// (i+0.5-cx, j+0.5-cy).
+type shearPlane struct {
+	pix    []float64
+	w, h   int
+	cx, cy float64
+}
+
+func newShearPlane(w, h int, cx, cy float64) *shearPlane {
+	return &shearPlane{make([]float64, w*h*4), w, h, cx, cy}
+}
+
+// shearRows fills dst, which must have the same rows as src, with src
+// sheared horizontally: the point (x, y) of dst is taken from (x-a*y, y)
+// of src, interpolating along the row.
+func shearRows(dst, src *shearPlane, a float64) {
+	parallelRows(0, dst.h, func(j0, j1 int) {
+		for j := j0; j < j1; j++ {
+			y := float64(j) + 0.5 - dst.cy
+			row := src.pix[j*src.w*4 : (j+1)*src.w*4]
+			out := dst.pix[j*dst.w*4:]
+			for i := 0; i < dst.w; i++ {
+				x := float64(i) + 0.5 - dst.cx
+				cubicSamples(out[i*4:i*4+4], row, 4, src.w, x-a*y+src.cx-0.5)
+			}
+		}
+	})
+}
+
+// shearCols fills dst, which must have the same columns as src, with src
+// sheared vertically: the point (x, y) of dst is taken from (x, y-b*x) of
+// src, interpolating along the column.
+func shearCols(dst, src *shearPlane, b float64) {
+	parallelRows(0, dst.h, func(j0, j1 int) {
+		for j := j0; j < j1; j++ {
+			y := float64(j) + 0.5 - dst.cy
+			out := dst.pix[j*dst.w*4:]
+			for i := 0; i < dst.w; i++ {
+				x := float64(i) + 0.5 - dst.cx
+				cubicSamples(out[i*4:i*4+4], src.pix[i*4:], src.w*4, src.h, y-b*x+src.cy-0.5)
+			}
+		}
+	})
+}
+
+// cubicSamples sets out to the Catmull-Rom interpolation at the
+// fractional index f of the n four-channel samples of s, which are stride
+// apart. Samples beyond either end are transparent.
+func cubicSamples(out, s []float64, stride, n int, f float64) {
+	fl := math.Floor(f)
+	k, t := int(fl), f-fl
+	t2, t3 := t*t, t*t*t
+	w := [4]float64{
+		(-t3 + 2*t2 - t) / 2,
+		(3*t3 - 5*t2 + 2) / 2,
+		(-3*t3 + 4*t2 + t) / 2,
+		(t3 - t2) / 2,
+	}
+	out[0], out[1], out[2], out[3] = 0, 0, 0, 0
+	for i, wi := range w {
+		if m := k - 1 + i; m >= 0 && m < n && wi != 0 {
+			p := s[m*stride : m*stride+4]
+			out[0] += wi * p[0]
+			out[1] += wi * p[1]
+			out[2] += wi * p[2]
+			out[3] += wi * p[3]
+		}
+	}
+}
+
+// rotateShear rotates src clockwise by angle about its center, placing the
+// center at that of dst. It turns src by the nearest whole number of
+// quarter turns, which is exact, and then rotates by the remainder with
+// three shears, each of which resamples along one axis with a cubic
+// kernel. Every pixel of dst is written; those outside the rotated image
+// become transparent.
+func rotateShear(dst draw.Image, src image.Image, angle float64) {
+	k := math.Floor(angle/(math.Pi/2) + 0.5)
+	angle -= k * math.Pi / 2
+	s := rotateQuarters(src, int(k))
+
+	// The clockwise rotation matrix of the forward mapping factors as
+	//	|c -s|   |1 a| |1 0| |1 a|
+	//	|s  c| = |0 1| |s 1| |0 1|
+	// where a = -tan(angle/2). With |angle| <= Pi/4, each shear moves a
+	// pixel by at most about half the image size.
+	sin := math.Sin(angle)
+	a := -math.Tan(angle / 2)
+
+	sb, db := s.Bounds(), dst.Bounds()
+	sw, sh, dw, dh := sb.Dx(), sb.Dy(), db.Dx(), db.Dy()
+	p0 := newShearPlane(sw, sh, float64(sw)/2, float64(sh)/2)
+	for j := 0; j < sh; j++ {
+		row := s.Pix[j*s.Stride:]
+		for i := 0; i < sw*4; i++ {
+			p0.pix[j*sw*4+i] = float64(row[i])
+		}
+	}
+
+	// The first shear keeps the rows of src and widens them, the second
+	// keeps those columns and resamples onto the rows of dst, and the
+	// last keeps those rows and resamples onto the columns of dst.
+	w1 := sw + int(math.Ceil(math.Abs(a)*float64(sh))) + 2
+	p1 := newShearPlane(w1, sh, float64(w1)/2, p0.cy)
+	shearRows(p1, p0, a)
+	p2 := newShearPlane(w1, dh, p1.cx, float64(dh)/2)
+	shearCols(p2, p1, sin)
+	p3 := newShearPlane(dw, dh, float64(dw)/2, p2.cy)
+	shearRows(p3, p2, a)
+
+	d, ok := dst.(*image.RGBA)
+	if !ok {
+		d = image.NewRGBA(db)
+	}
+	for j := 0; j < dh; j++ {
+		out := d.Pix[(db.Min.Y+j-d.Rect.Min.Y)*d.Stride+(db.Min.X-d.Rect.Min.X)*4:]
+		for i := 0; i < dw*4; i += 4 {
+			// The cubic kernel can overshoot. Where alpha does,
+			// scale the color down with it to keep its hue, and
+			// keep the colors within the premultiplied alpha.
+			p := p3.pix[j*dw*4+i : j*dw*4+i+4]
+			k := 1.0
+			if p[3] > 0xff {
+				k = 0xff / p[3]
+			}
+			a := clamp8(p[3])
+			out[i+0] = clampAlpha(k*p[0], float64(a))
+			out[i+1] = clampAlpha(k*p[1], float64(a))
+			out[i+2] = clampAlpha(k*p[2], float64(a))
+			out[i+3] = a
+		}
+	}
+	if !ok {
+		draw.Draw(dst, db, d, db.Min, draw.Src)
+	}
+}
+
+// rotateQuarters returns a copy of src, at the origin, rotated clockwise
+// by k quarter turns. k may be negative.
+func rotateQuarters(src image.Image, k int) *image.RGBA {
+	k %= 4
+	if k < 0 {
+		k += 4
+	}
+	sb := src.Bounds()
+	s := crop(src, sb)
+	if k == 0 {
+		return s
+	}
+	w, h := sb.Dx(), sb.Dy()
+	var d *image.RGBA
+	if k == 2 {
+		d = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		d = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// The destination of src pixel (x, y).
+			var dx, dy int
+			switch k {
+			case 1:
+				dx, dy = h-1-y, x
+			case 2:
+				dx, dy = w-1-x, h-1-y
+			case 3:
+				dx, dy = y, w-1-x
+			}
+			so, do := y*s.Stride+x*4, dy*d.Stride+dx*4
+			copy(d.Pix[do:do+4], s.Pix[so:so+4])
+		}
+	}
+	return d
+}
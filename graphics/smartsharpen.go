@@ -0,0 +1,97 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+const (
+	// focusRadius is the radius of the window over which SmartSharpen
+	// measures how much detail there is around each pixel.
+	focusRadius = 3
+	// focusKnee is the variance of the luma Laplacian at which
+	// SmartSharpen applies half the amount. Smooth and defocused regions
+	// fall well below it, textured and in-focus ones well above.
+	focusKnee = 400
+)
+
+// SmartSharpen is like UnsharpMask with the default standard deviation,
+// but sharpens each pixel in proportion to how much in focus its
+// neighborhood is, so that smooth and defocused regions, where sharpening
+// would only amplify noise, are left mostly as they are. Focus is measured
+// by the local variance of the Laplacian of the luma. The amount scales
+// the difference between the image and its blur that is added back in
+// fully focused regions.
+func SmartSharpen(dst draw.Image, src image.Image, amount float64) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	s := crop(src, b)
+	blurred := image.NewRGBA(b)
+	if err := Blur(blurred, src, &BlurOptions{StdDev: DefaultStdDev}); err != nil {
+		return err
+	}
+	focus := focusMap(s)
+
+	w := b.Dx()
+	parallelRows(0, b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			off := y * s.Stride
+			for x := 0; x < w; x, off = x+1, off+4 {
+				k := amount * focus[y*w+x]
+				sharpenPixel(s.Pix[off:off+4], blurred.Pix[off:off+4], k, false)
+			}
+		}
+	})
+
+	draw.Draw(dst, b, s, image.Point{}, draw.Src)
+	return nil
+}
+
+// focusMap returns, for each pixel of m, a weight in [0, 1) that grows
+// with the variance of the Laplacian of the luma over the focusRadius
+// window around it.
+func focusMap(m *image.RGBA) []float64 {
+	w, h := m.Rect.Dx(), m.Rect.Dy()
+	lum := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := m.Pix[y*m.Stride+x*4:]
+			lum[y*w+x] = luma(p[0], p[1], p[2])
+		}
+	}
+	at := func(x, y int) float64 {
+		return lum[clampInt(y, 0, h-1)*w+clampInt(x, 0, w-1)]
+	}
+
+	// The Laplacian and its square, whose window means give the variance.
+	f := newFloatImage(w, h, 2)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := 4*at(x, y) - at(x-1, y) - at(x+1, y) - at(x, y-1) - at(x, y+1)
+			f.pix[(y*w+x)*2], f.pix[(y*w+x)*2+1] = l, l*l
+		}
+	}
+	f.boxRows(focusRadius)
+	f.boxCols(focusRadius)
+
+	focus := make([]float64, w*h)
+	for i := range focus {
+		mean, sq := f.pix[i*2], f.pix[i*2+1]
+		v := math.Max(sq-mean*mean, 0)
+		focus[i] = v / (v + focusKnee)
+	}
+	return focus
+}
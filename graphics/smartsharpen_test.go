@@ -0,0 +1,65 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"testing"
+)
+
+// meanChange returns the mean absolute difference between the color
+// channels of m0 and m1 within r.
+func meanChange(m0, m1 *image.RGBA, r image.Rectangle) float64 {
+	var sum, n int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			p, q := m0.Pix[m0.PixOffset(x, y):], m1.Pix[m1.PixOffset(x, y):]
+			for c := 0; c < 3; c++ {
+				d := int(p[c]) - int(q[c])
+				if d < 0 {
+					d = -d
+				}
+				sum += d
+				n++
+			}
+		}
+	}
+	return float64(sum) / float64(n)
+}
+
+func TestSmartSharpen(t *testing.T) {
+	// Sharp noise on the left, and the same noise heavily blurred, as if
+	// out of focus, on the right.
+	src := NewNoise(80, 40, 1)
+	left, right := image.Rect(0, 0, 40, 40), image.Rect(40, 0, 80, 40)
+	soft := image.NewRGBA(right)
+	if err := Blur(soft, src, &BlurOptions{StdDev: 3}); err != nil {
+		t.Fatal(err)
+	}
+	draw.Draw(src, right, soft, right.Min, draw.Src)
+
+	plain := image.NewRGBA(src.Bounds())
+	if err := UnsharpMask(plain, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	smart := image.NewRGBA(src.Bounds())
+	if err := SmartSharpen(smart, src, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Keep away from the boundary between the halves.
+	left, right = left.Inset(4), right.Inset(4)
+	detail, blurred := meanChange(smart, src, left), meanChange(smart, src, right)
+	if detail <= 4*blurred {
+		t.Errorf("got mean change %.3f in detail, %.3f out of focus; want detail much higher", detail, blurred)
+	}
+	if p := meanChange(plain, src, right); blurred >= p/2 {
+		t.Errorf("got mean change %.3f out of focus, want well below UnsharpMask's %.3f", blurred, p)
+	}
+	if p := meanChange(plain, src, left); detail < p/2 {
+		t.Errorf("got mean change %.3f in detail, want near UnsharpMask's %.3f", detail, p)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+// sobel computes the horizontal and vertical Sobel gradients of a w*h
+// intensity plane stored in row major order. Samples beyond the edge are
+// clamped to the nearest edge sample.
+func sobel(lum []float64, w, h int) (gx, gy []float64) {
+	gx = make([]float64, w*h)
+	gy = make([]float64, w*h)
+	at := func(x, y int) float64 {
+		if x < 0 {
+			x = 0
+		} else if x >= w {
+			x = w - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y >= h {
+			y = h - 1
+		}
+		return lum[y*w+x]
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			tl, t, tr := at(x-1, y-1), at(x, y-1), at(x+1, y-1)
+			l, r := at(x-1, y), at(x+1, y)
+			bl, b, br := at(x-1, y+1), at(x, y+1), at(x+1, y+1)
+			gx[y*w+x] = (tr + 2*r + br) - (tl + 2*l + bl)
+			gy[y*w+x] = (bl + 2*b + br) - (tl + 2*t + tr)
+		}
+	}
+	return gx, gy
+}
+
+// luma returns the Rec. 601 luma of an 8-bit RGB triple.
+func luma(r, g, b uint8) float64 {
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
@@ -0,0 +1,125 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// SpriteSheet scales each of srcs to fit within a cell the size of cell,
+// preserving its aspect ratio, and tiles them left to right, top to bottom
+// into a grid with cols columns. Each sprite is centered in its cell. Cells
+// not covered by a sprite, including those of a partial final row, are
+// transparent.
+func SpriteSheet(srcs []image.Image, cols int, cell image.Rectangle) (*image.RGBA, error) {
+	if cols < 1 {
+		return nil, errors.New("graphics: cols must be positive")
+	}
+	if cell.Empty() {
+		return nil, errors.New("graphics: cell is empty")
+	}
+	cw, ch := cell.Dx(), cell.Dy()
+	rows := (len(srcs) + cols - 1) / cols
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*cw, rows*ch))
+
+	for i, src := range srcs {
+		if src == nil {
+			return nil, ErrNilSrc
+		}
+		sb := src.Bounds()
+		if sb.Empty() {
+			continue
+		}
+		w, h := fitSize(sb.Dx(), sb.Dy(), cw, ch)
+		buf := image.NewRGBA(image.Rect(0, 0, w, h))
+		if err := Scale(buf, src); err != nil {
+			return nil, err
+		}
+		pt := image.Pt((i%cols)*cw+(cw-w)/2, (i/cols)*ch+(ch-h)/2)
+		draw.Draw(sheet, buf.Bounds().Add(pt), buf, image.ZP, draw.Src)
+	}
+	return sheet, nil
+}
+
+// ContactSheet thumbnails each of srcs to thumbW by thumbH, scaling and
+// cropping it as Thumbnail does, and lays them out left to right, top to
+// bottom on a grid with cols columns, for previewing a set of photos or
+// video frames. Thumbnails are separated from each other and from the edges
+// of the sheet by gap pixels of bg, which also fills the cells of a partial
+// final row and shows through transparent parts of the thumbnails. Nil and
+// empty images leave their cells blank. It returns nil if cols, thumbW or
+// thumbH is not positive, and treats a negative gap as zero.
+func ContactSheet(srcs []image.Image, cols int, thumbW, thumbH int, gap int, bg color.Color) *image.RGBA {
+	if cols < 1 || thumbW < 1 || thumbH < 1 {
+		return nil
+	}
+	gap = max0(gap)
+	rows := (len(srcs) + cols - 1) / cols
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*(thumbW+gap)+gap, rows*(thumbH+gap)+gap))
+	if bg != nil {
+		draw.Draw(sheet, sheet.Bounds(), image.NewUniform(bg), image.ZP, draw.Src)
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, thumbW, thumbH))
+	for i, src := range srcs {
+		if src == nil || src.Bounds().Empty() {
+			continue
+		}
+		if err := Thumbnail(thumb, src); err != nil {
+			continue
+		}
+		pt := image.Pt(gap+(i%cols)*(thumbW+gap), gap+(i/cols)*(thumbH+gap))
+		draw.Draw(sheet, thumb.Bounds().Add(pt), thumb, image.ZP, draw.Over)
+	}
+	return sheet
+}
+
+// fitSize returns the largest size with the aspect ratio of w by h that
+// fits within maxW by maxH. Neither dimension is less than one.
+func fitSize(w, h, maxW, maxH int) (int, int) {
+	if w*maxH > h*maxW {
+		h = (h*maxW + w/2) / w
+		w = maxW
+	} else {
+		w = (w*maxH + h/2) / h
+		h = maxH
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// SliceGrid splits src, a sprite sheet of cols by rows equally sized
+// frames, into its frames in left to right, top to bottom order. Each frame
+// is translated to the origin. It is an error for the dimensions of src not
+// to divide evenly into the grid.
+func SliceGrid(src image.Image, cols, rows int) ([]*image.RGBA, error) {
+	if src == nil {
+		return nil, ErrNilSrc
+	}
+	if cols < 1 || rows < 1 {
+		return nil, errors.New("graphics: cols and rows must be positive")
+	}
+	b := src.Bounds()
+	if b.Dx()%cols != 0 || b.Dy()%rows != 0 {
+		return nil, errors.New("graphics: sprite sheet does not divide evenly")
+	}
+	w, h := b.Dx()/cols, b.Dy()/rows
+	frames := make([]*image.RGBA, 0, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			r := image.Rect(0, 0, w, h).Add(b.Min).Add(image.Pt(x*w, y*h))
+			frames = append(frames, crop(src, r))
+		}
+	}
+	return frames, nil
+}
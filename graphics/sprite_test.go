@@ -0,0 +1,149 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func newUniformRGBA(r image.Rectangle, c color.Color) *image.RGBA {
+	m := image.NewRGBA(r)
+	draw.Draw(m, r, image.NewUniform(c), image.ZP, draw.Src)
+	return m
+}
+
+func TestSpriteSheet(t *testing.T) {
+	colors := []color.RGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xff, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+		{0xff, 0xff, 0x00, 0xff},
+		{0x00, 0xff, 0xff, 0xff},
+	}
+	srcs := make([]image.Image, len(colors))
+	for i, c := range colors {
+		srcs[i] = newUniformRGBA(image.Rect(0, 0, 64, 64), c)
+	}
+
+	sheet, err := SpriteSheet(srcs, 3, image.Rect(0, 0, 32, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sheet.Bounds(), image.Rect(0, 0, 96, 64); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	for i, c := range colors {
+		x, y := (i%3)*32, (i/3)*32
+		for _, pt := range []image.Point{{x, y}, {x + 16, y + 16}, {x + 31, y + 31}} {
+			if got := sheet.RGBAAt(pt.X, pt.Y); got != c {
+				t.Errorf("sprite %d at %v: got %v want %v", i, pt, got, c)
+			}
+		}
+	}
+	// The sixth cell, at the end of the partial row, is transparent.
+	if got := sheet.RGBAAt(80, 48); got != (color.RGBA{}) {
+		t.Errorf("empty cell: got %v want transparent", got)
+	}
+}
+
+func TestSpriteSheetAspect(t *testing.T) {
+	src := newUniformRGBA(image.Rect(0, 0, 40, 20), color.White)
+	sheet, err := SpriteSheet([]image.Image{src}, 1, image.Rect(0, 0, 20, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A 2:1 sprite is letterboxed in the middle of a square cell.
+	if got := sheet.RGBAAt(10, 2); got.A != 0 {
+		t.Errorf("letterbox: got %v want transparent", got)
+	}
+	if got := sheet.RGBAAt(10, 10); got.A != 0xff {
+		t.Errorf("center: got %v want opaque", got)
+	}
+}
+
+func TestContactSheet(t *testing.T) {
+	colors := []color.RGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xff, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+		{0xff, 0xff, 0x00, 0xff},
+		{0x00, 0xff, 0xff, 0xff},
+		{0xff, 0x00, 0xff, 0xff},
+		{0xff, 0xff, 0xff, 0xff},
+	}
+	srcs := make([]image.Image, len(colors))
+	for i, c := range colors {
+		// Vary the sizes and aspect ratios; each is cropped to fill.
+		srcs[i] = newUniformRGBA(image.Rect(0, 0, 30+10*i, 60-5*i), c)
+	}
+	bg := color.RGBA{0x20, 0x20, 0x20, 0xff}
+
+	sheet := ContactSheet(srcs, 3, 20, 16, 4, bg)
+	// Three rows of three, the last holding one, with gaps all round.
+	if got, want := sheet.Bounds(), image.Rect(0, 0, 3*20+4*4, 3*16+4*4); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	for i, c := range colors {
+		x, y := 4+(i%3)*24, 4+(i/3)*20
+		want := newUniformRGBA(image.Rect(x, y, x+20, y+16), c)
+		if err := graphicstest.ImageWithinTolerance(sheet.SubImage(want.Rect), want, 0); err != nil {
+			t.Errorf("thumbnail %d: %v", i, err)
+		}
+		// The gap before each thumbnail is background.
+		if got := sheet.RGBAAt(x-1, y-1); got != bg {
+			t.Errorf("gap before thumbnail %d: got %v want %v", i, got, bg)
+		}
+	}
+	// The rest of the ragged final row is background.
+	for _, pt := range []image.Point{{28, 52}, {60, 56}, {75, 63}} {
+		if got := sheet.RGBAAt(pt.X, pt.Y); got != bg {
+			t.Errorf("empty cell at %v: got %v want %v", pt, got, bg)
+		}
+	}
+
+	if sheet := ContactSheet(srcs, 0, 20, 16, 4, bg); sheet != nil {
+		t.Error("zero columns: got a sheet, want nil")
+	}
+}
+
+func TestSliceGrid(t *testing.T) {
+	colors := []color.RGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xff, 0x00, 0xff},
+		{0x00, 0x00, 0xff, 0xff},
+		{0xff, 0xff, 0xff, 0xff},
+	}
+	sheet := image.NewRGBA(image.Rect(10, 10, 30, 26))
+	for i, c := range colors {
+		r := image.Rect(0, 0, 10, 8).Add(image.Pt(10+(i%2)*10, 10+(i/2)*8))
+		draw.Draw(sheet, r, image.NewUniform(c), image.ZP, draw.Src)
+	}
+
+	frames, err := SliceGrid(sheet, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("got %d frames want 4", len(frames))
+	}
+	for i, f := range frames {
+		if got, want := f.Bounds(), image.Rect(0, 0, 10, 8); !got.Eq(want) {
+			t.Errorf("frame %d: got bounds %v want %v", i, got, want)
+			continue
+		}
+		want := newUniformRGBA(f.Bounds(), colors[i])
+		if err := graphicstest.ImageWithinTolerance(f, want, 0); err != nil {
+			t.Errorf("frame %d: %v", i, err)
+		}
+	}
+
+	if _, err := SliceGrid(sheet, 3, 2); err == nil {
+		t.Error("expected error for uneven grid")
+	}
+}
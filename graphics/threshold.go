@@ -0,0 +1,53 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+)
+
+// AdaptiveThreshold binarizes src into dst by comparing each pixel with
+// the mean of the windowSize by windowSize window centered on it, minus c.
+// Pixels above that local threshold become white and the rest black. Near
+// the borders the window is shrunk to the part that lies within src. The
+// local means come from a summed-area table, so the cost does not depend
+// on windowSize.
+func AdaptiveThreshold(dst, src *image.Gray, windowSize int, c int) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if windowSize < 1 {
+		return errors.New("graphics: window size must be positive")
+	}
+
+	sb := src.Bounds()
+	b := dst.Bounds().Intersect(sb)
+	t := IntegralImage(src)
+	r := windowSize / 2
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			so := (y-src.Rect.Min.Y)*src.Stride + (b.Min.X - src.Rect.Min.X)
+			do := (y-dst.Rect.Min.Y)*dst.Stride + (b.Min.X - dst.Rect.Min.X)
+			for x := b.Min.X; x < b.Max.X; x, so, do = x+1, so+1, do+1 {
+				// The window, relative to sb.Min and clipped to it.
+				px, py := x-sb.Min.X, y-sb.Min.Y
+				w := image.Rect(px-r, py-r, px-r+windowSize, py-r+windowSize).
+					Intersect(image.Rect(0, 0, sb.Dx(), sb.Dy()))
+				n := int64(w.Dx() * w.Dy())
+				// Compare v > sum/n - c without dividing.
+				if int64(int(src.Pix[so])+c)*n > IntegralSum(t, w) {
+					dst.Pix[do] = 0xff
+				} else {
+					dst.Pix[do] = 0
+				}
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"testing"
+)
+
+func TestAdaptiveThreshold(t *testing.T) {
+	// A page lit from the left, with "text" strokes drawn 0x30 darker than
+	// the paper every eighth column.
+	const w, h = 96, 16
+	src := image.NewGray(image.Rect(0, 0, w, h))
+	isText := func(x, y int) bool { return x%8 == 4 && y > 3 && y < 12 }
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 0xf0 - x*2
+			if isText(x, y) {
+				v -= 0x30
+			}
+			src.Pix[y*src.Stride+x] = uint8(v)
+		}
+	}
+
+	// A global threshold at mid-gray marks the dim side of the paper as
+	// text.
+	global := 0
+	for x := 0; x < w; x++ {
+		if src.Pix[x] < 0x80 {
+			global++
+		}
+	}
+	if global == 0 {
+		t.Fatal("test image does not defeat a global threshold")
+	}
+
+	dst := image.NewGray(src.Bounds())
+	if err := AdaptiveThreshold(dst, src, 7, 8); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			want := uint8(0xff)
+			if isText(x, y) {
+				want = 0
+			}
+			if got := dst.GrayAt(x, y).Y; got != want {
+				t.Errorf("(%d, %d): got 0x%02x want 0x%02x", x, y, got, want)
+			}
+		}
+	}
+}
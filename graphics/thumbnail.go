@@ -11,6 +11,20 @@ import (
 
 // Thumbnail scales and crops src so it fits in dst.
 func Thumbnail(dst draw.Image, src image.Image) error {
+	return thumbnail(dst, src, scale, image.Rectangle{}, nil)
+}
+
+// thumbnail is like Thumbnail, scaling with sc and checking cancel. If
+// focus is not empty, the crop is centered on it rather than on src, as far
+// as the edges of src allow.
+func thumbnail(dst draw.Image, src image.Image, sc scaler, focus image.Rectangle, cancel <-chan struct{}) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
 	// Scale down src in the dimension that is closer to dst.
 	sb := src.Bounds()
 	db := dst.Bounds()
@@ -24,18 +38,22 @@ func Thumbnail(dst draw.Image, src image.Image) error {
 	}
 
 	buf := image.NewRGBA(b)
-	if err := Scale(buf, src); err != nil {
+	if err := sc(buf, src, cancel); err != nil {
 		return err
 	}
 
-	// Crop.
-	// TODO(crawshaw): improve on center-alignment.
-	var pt image.Point
-	if rx < ry {
-		pt.Y = (b.Dy() - db.Dy()) / 2
-	} else {
-		pt.X = (b.Dx() - db.Dx()) / 2
+	// Crop around the center of focus, in buf's co-ordinates.
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	if !focus.Empty() {
+		kx := float64(b.Dx()) / float64(sb.Dx())
+		ky := float64(b.Dy()) / float64(sb.Dy())
+		cx = (float64(focus.Min.X+focus.Max.X)/2 - float64(sb.Min.X)) * kx
+		cy = (float64(focus.Min.Y+focus.Max.Y)/2 - float64(sb.Min.Y)) * ky
 	}
+	pt := image.Pt(
+		clampInt(int(cx-float64(db.Dx())/2), 0, max0(b.Dx()-db.Dx())),
+		clampInt(int(cy-float64(db.Dy())/2), 0, max0(b.Dy()-db.Dy())),
+	)
 	draw.Draw(dst, db, buf, pt, draw.Src)
 	return nil
 }
@@ -0,0 +1,245 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/convolve"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// DefaultTileSize is the tile edge length used when none is given.
+var DefaultTileSize = 256
+
+// TileReader provides rectangular regions of an image that may be too
+// large to hold in memory.
+type TileReader interface {
+	// Bounds returns the bounds of the whole image.
+	Bounds() image.Rectangle
+	// ReadTile returns the region r of the image. The bounds of the
+	// returned image are r.
+	ReadTile(r image.Rectangle) (image.Image, error)
+}
+
+// TileWriter accepts rectangular regions of an image that may be too
+// large to hold in memory.
+type TileWriter interface {
+	// WriteTile stores m, whose bounds are r, as the region r of the image.
+	WriteTile(r image.Rectangle, m image.Image) error
+}
+
+// ImageTiles adapts an in-memory image to the TileReader and TileWriter
+// interfaces.
+type ImageTiles struct {
+	draw.Image
+}
+
+func (t ImageTiles) ReadTile(r image.Rectangle) (image.Image, error) {
+	if s, ok := t.Image.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return s.SubImage(r), nil
+	}
+	m := image.NewRGBA(r)
+	draw.Draw(m, r, t.Image, r.Min, draw.Src)
+	return m, nil
+}
+
+func (t ImageTiles) WriteTile(r image.Rectangle, m image.Image) error {
+	draw.Draw(t.Image, r, m, r.Min, draw.Src)
+	return nil
+}
+
+// eachTile calls fn for each tile of b, in row major order, stopping at the
+// first error.
+func eachTile(b image.Rectangle, size int, fn func(r image.Rectangle) error) error {
+	if size < 1 {
+		size = DefaultTileSize
+	}
+	for y := b.Min.Y; y < b.Max.Y; y += size {
+		for x := b.Min.X; x < b.Max.X; x += size {
+			r := image.Rect(x, y, x+size, y+size).Intersect(b)
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BlurTiled is like Blur, but reads src and writes dst one tile at a time,
+// so only a single tile and its surrounding halo are held in memory. The
+// halo is as wide as the blur kernel's radius, and is filled beyond the
+// edges of src as opt.Edge describes, so the output is identical to that
+// of Blur. The dst image has the bounds of src.
+func BlurTiled(dst TileWriter, src TileReader, opt *BlurOptions, tileSize int) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	b := src.Bounds()
+	k, err := blurKernel(opt, b)
+	if err != nil {
+		return err
+	}
+	hx, hy := len(k.X)/2, len(k.Y)/2
+	mode, pad := opt.edge().padMode()
+	return eachTile(b, tileSize, func(r image.Rectangle) error {
+		hr := image.Rect(r.Min.X-hx, r.Min.Y-hy, r.Max.X+hx, r.Max.Y+hy)
+		var in image.Image
+		var err error
+		if pad {
+			in, err = readPadded(src, hr, mode)
+		} else {
+			in, err = src.ReadTile(hr.Intersect(b))
+		}
+		if err != nil {
+			return err
+		}
+		buf := image.NewRGBA(r)
+		if err := convolve.Convolve(buf, in, k); err != nil {
+			return err
+		}
+		return dst.WriteTile(r, buf)
+	})
+}
+
+// padRun is a run of n pixels along one axis, starting at d, that are
+// filled from the source pixels starting at s, stepping by step, which is
+// -1, 0 or 1.
+type padRun struct {
+	d, s, step, n int
+}
+
+// padRuns returns the runs that fill [lo, hi) from the n pixels starting
+// at min, as Pad does with mode. Pixels that mode leaves empty are in no
+// run.
+func padRuns(lo, hi, min, n int, mode PadMode) []padRun {
+	var runs []padRun
+	for i := lo; i < hi; i++ {
+		j, ok := padIndex(i-min, n, mode)
+		if !ok {
+			continue
+		}
+		j += min
+		if len(runs) > 0 {
+			r := &runs[len(runs)-1]
+			last := r.s + (r.n-1)*r.step
+			if r.d+r.n == i && (r.n == 1 && j-last >= -1 && j-last <= 1 || r.n > 1 && j-last == r.step) {
+				r.step = j - last
+				r.n++
+				continue
+			}
+		}
+		runs = append(runs, padRun{i, j, 0, 1})
+	}
+	return runs
+}
+
+// span returns the source range [lo, hi) that r reads.
+func (r padRun) span() (lo, hi int) {
+	lo, hi = r.s, r.s+(r.n-1)*r.step
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo, hi + 1
+}
+
+// readPadded returns the region r of src, with the parts beyond the edges
+// of src filled as Pad does with mode. Only the source pixels needed are
+// read, a rectangle for each pair of horizontal and vertical runs.
+func readPadded(src TileReader, r image.Rectangle, mode PadMode) (*image.RGBA, error) {
+	b := src.Bounds()
+	m := image.NewRGBA(r)
+	xs := padRuns(r.Min.X, r.Max.X, b.Min.X, b.Dx(), mode)
+	ys := padRuns(r.Min.Y, r.Max.Y, b.Min.Y, b.Dy(), mode)
+	for _, ry := range ys {
+		y0, y1 := ry.span()
+		for _, rx := range xs {
+			x0, x1 := rx.span()
+			t, err := src.ReadTile(image.Rect(x0, y0, x1, y1))
+			if err != nil {
+				return nil, err
+			}
+			s := toRGBA(t)
+			for i := 0; i < ry.n; i++ {
+				sy := ry.s + i*ry.step
+				for j := 0; j < rx.n; j++ {
+					sx := rx.s + j*rx.step
+					copy(m.Pix[m.PixOffset(rx.d+j, ry.d+i):][:4], s.Pix[s.PixOffset(sx, sy):][:4])
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// TransformTiled is like Transform, but produces the dstBounds region of
+// dst one tile at a time. For each tile, only the part of src that the tile
+// samples from, plus a small halo for interpolation, is read.
+func (a Affine) TransformTiled(dst TileWriter, dstBounds image.Rectangle, src TileReader, i interp.Interp, tileSize int) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+
+	srcb := src.Bounds()
+	return eachTile(dstBounds, tileSize, func(r image.Rectangle) error {
+		// Find the source region sampled by the tile's pixel centers.
+		minX, minY := math.Inf(+1), math.Inf(+1)
+		maxX, maxY := math.Inf(-1), math.Inf(-1)
+		for _, p := range []image.Point{
+			r.Min, {r.Max.X - 1, r.Min.Y}, {r.Min.X, r.Max.Y - 1}, r.Max.Sub(image.Pt(1, 1)),
+		} {
+			x, y := a.pt(p.X, p.Y)
+			minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+		// Interpolation reads one pixel beyond each sample, and treats the
+		// half pixel next to the bounds as an edge, so a halo of two pixels
+		// keeps tile edges indistinguishable from the interior.
+		const halo = 2
+		sr := image.Rect(
+			int(math.Floor(minX))-halo, int(math.Floor(minY))-halo,
+			int(math.Ceil(maxX))+halo, int(math.Ceil(maxY))+halo,
+		).Intersect(srcb)
+
+		buf := image.NewRGBA(r)
+		if !sr.Empty() {
+			in, err := src.ReadTile(sr)
+			if err != nil {
+				return err
+			}
+			if err := a.transformTile(buf, in, srcb, i); err != nil {
+				return err
+			}
+		}
+		return dst.WriteTile(r, buf)
+	})
+}
+
+// transformTile is like Transform, except that dst pixels are included or
+// excluded based on srcb, the bounds of the whole source image, rather than
+// the bounds of the src tile.
+func (a Affine) transformTile(dst *image.RGBA, src image.Image, srcb image.Rectangle, i interp.Interp) error {
+	b := dst.Bounds()
+	tb := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sx, sy := a.pt(x, y)
+			if inBounds(srcb, sx, sy) && inBounds(tb, sx, sy) {
+				dst.Set(x, y, i.Interp(src, sx, sy))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"testing"
+)
+
+func TestBlurTiled(t *testing.T) {
+	src := NewNoise(53, 37, 9)
+	for _, edge := range []BlurEdge{BlurRenormalize, BlurExtend, BlurReflect, BlurWrap, BlurZero} {
+		// The larger deviation reaches across the whole image.
+		for _, sd := range []float64{1.2, 10} {
+			opt := &BlurOptions{StdDev: sd, Edge: edge}
+			want := image.NewRGBA(src.Bounds())
+			if err := Blur(want, src, opt); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, size := range []int{5, 16, 100} {
+				got := image.NewRGBA(src.Bounds())
+				if err := BlurTiled(ImageTiles{got}, ImageTiles{src}, opt, size); err != nil {
+					t.Fatal(err)
+				}
+				if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+					t.Errorf("edge %d, deviation %v, tile size %d: %v", edge, sd, size, err)
+				}
+			}
+		}
+	}
+}
+
+func TestTransformTiled(t *testing.T) {
+	src := NewNoise(40, 30, 4)
+	b := image.Rect(0, 0, 50, 50)
+	a := I.Rotate(0.3).Scale(1.2, 1.1).CenterFit(b, src.Bounds())
+
+	want := image.NewRGBA(b)
+	if err := a.Transform(want, src, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{7, 16} {
+		got := image.NewRGBA(b)
+		if err := a.TransformTiled(ImageTiles{got}, b, ImageTiles{src}, interp.Bilinear, size); err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(got, want, 0); err != nil {
+			t.Errorf("tile size %d: %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// tiltShiftLevels is the number of blurs, of evenly spaced standard
+// deviations up to the maximum, between which TiltShift interpolates.
+const tiltShiftLevels = 4
+
+// TiltShift simulates a tilt-shift lens, as in miniature photography: it
+// keeps the rows of src within focusBand sharp and blurs those above and
+// below it progressively more, and draws the result onto dst. Only the
+// vertical extent of focusBand matters. The standard deviation of the blur
+// ramps smoothly from zero at the edges of the band to maxSigma at a
+// distance of the band's height from them, and is maxSigma beyond.
+func TiltShift(dst draw.Image, src image.Image, focusBand image.Rectangle, maxSigma float64) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if maxSigma < 0 {
+		return ErrNegativeStdDev
+	}
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	s := crop(src, b)
+	if maxSigma == 0 {
+		draw.Draw(dst, b, s, image.Point{}, draw.Src)
+		return nil
+	}
+
+	// levels[k] is src blurred with a standard deviation of k/n of the
+	// maximum.
+	var levels [tiltShiftLevels + 1]*image.RGBA
+	levels[0] = s
+	for k := 1; k <= tiltShiftLevels; k++ {
+		levels[k] = image.NewRGBA(b)
+		opt := &BlurOptions{StdDev: maxSigma * float64(k) / tiltShiftLevels}
+		if err := Blur(levels[k], src, opt); err != nil {
+			return err
+		}
+	}
+
+	ramp := math.Max(float64(focusBand.Dy()), 1)
+	out := image.NewRGBA(b)
+	parallelRows(0, b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			// The distance of the row's center from the band.
+			cy := float64(b.Min.Y+y) + 0.5
+			d := math.Max(float64(focusBand.Min.Y)-cy, cy-float64(focusBand.Max.Y))
+			t := math.Min(math.Max(d/ramp, 0), 1)
+			f := t * t * (3 - 2*t) * tiltShiftLevels
+			k := int(f)
+			if k == tiltShiftLevels {
+				k--
+			}
+			f -= float64(k)
+
+			row := out.Pix[y*out.Stride : y*out.Stride+b.Dx()*4]
+			p, q := levels[k].Pix[y*levels[k].Stride:], levels[k+1].Pix[y*levels[k+1].Stride:]
+			for i := range row {
+				row[i] = clamp8(float64(p[i])*(1-f) + float64(q[i])*f)
+			}
+		}
+	})
+	draw.Draw(dst, b, out, image.Point{}, draw.Src)
+	return nil
+}
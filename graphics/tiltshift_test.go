@@ -0,0 +1,42 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"testing"
+)
+
+func TestTiltShift(t *testing.T) {
+	src := NewNoise(40, 60, 1)
+	band := image.Rect(0, 25, 40, 35)
+	dst := image.NewRGBA(src.Bounds())
+	if err := TiltShift(dst, src, band, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// The band is untouched.
+	if err := graphicstest.ImageWithinTolerance(crop(dst, band), crop(src, band), 0); err != nil {
+		t.Errorf("focus band: %v", err)
+	}
+
+	// A band's height away, the blur is at its maximum.
+	full := image.NewRGBA(src.Bounds())
+	if err := Blur(full, src, &BlurOptions{StdDev: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []image.Rectangle{image.Rect(0, 0, 40, 15), image.Rect(0, 45, 40, 60)} {
+		if err := graphicstest.ImageWithinTolerance(crop(dst, r), crop(full, r), 0); err != nil {
+			t.Errorf("%v: %v", r, err)
+		}
+	}
+
+	// In between, the blur grows with the distance.
+	rows := func(y int) *image.RGBA { return crop(dst, image.Rect(0, y, 40, y+1)) }
+	if near, far := totalVariation(rows(22)), totalVariation(rows(18)); near <= far {
+		t.Errorf("got variation %.3f near the band, %.3f farther; want less blur near", near, far)
+	}
+}
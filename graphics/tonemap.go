@@ -0,0 +1,47 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"math"
+)
+
+// ToneMap compresses the high dynamic range image src into a displayable
+// 8-bit image with the global Reinhard operator.
+//
+// The channels of src are taken as linear light and multiplied by exposure,
+// so that 1 is a typical white; with an exposure above 1 the 16-bit range
+// holds values far brighter than white. The luminance L of each pixel is
+// mapped to L/(1+L), which leaves dark values almost unchanged and brings
+// arbitrarily bright ones below 1, and the color is scaled to match. The
+// result is gamma encoded with a gamma of 2.2. Alpha is preserved.
+func ToneMap(src *image.RGBA64, exposure float64) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c := src.RGBA64At(x, y)
+				if c.A == 0 {
+					continue
+				}
+				// Un-premultiply and scale by the exposure.
+				k := exposure / float64(c.A)
+				r, g, bl := float64(c.R)*k, float64(c.G)*k, float64(c.B)*k
+				l := 0.2126*r + 0.7152*g + 0.0722*bl
+				s := 1 / (1 + l)
+				a := float64(c.A) / 0xffff
+				off := (y-b.Min.Y)*dst.Stride + (x-b.Min.X)*4
+				for i, v := range [3]float64{r, g, bl} {
+					v = math.Pow(math.Min(v*s, 1), 1/2.2)
+					dst.Pix[off+i] = clamp8(0xff * v * a)
+				}
+				dst.Pix[off+3] = uint8(c.A >> 8)
+			}
+		}
+	})
+	return dst
+}
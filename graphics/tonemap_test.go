@@ -0,0 +1,62 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestToneMap(t *testing.T) {
+	// A gray ramp of linear values from 0 to 64 times white.
+	const exposure = 64
+	src := image.NewRGBA64(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		v := uint16(x * 0x101)
+		src.SetRGBA64(x, 0, color.RGBA64{v, v, v, 0xffff})
+	}
+	dst := ToneMap(src, exposure)
+
+	prev := -1
+	for x := 0; x < 256; x++ {
+		c := dst.RGBAAt(x, 0)
+		if c.R != c.G || c.G != c.B || c.A != 0xff {
+			t.Fatalf("x=%d: got %v, want opaque gray", x, c)
+		}
+		// The ordering is preserved, and even 64 times white is not
+		// clipped.
+		if int(c.R) < prev {
+			t.Errorf("x=%d: %d darker than %d", x, c.R, prev)
+		}
+		if c.R == 0xff {
+			t.Errorf("x=%d: clipped to white", x)
+		}
+		prev = int(c.R)
+	}
+
+	// Bright values are compressed far more than dark ones.
+	if lo, hi := dst.RGBAAt(2, 0).R-dst.RGBAAt(1, 0).R, dst.RGBAAt(255, 0).R-dst.RGBAAt(128, 0).R; hi >= lo {
+		t.Errorf("step at the top of the range (%d) not below step at the bottom (%d)", hi, lo)
+	}
+
+	// Dark values are barely compressed, and white maps to half before
+	// gamma encoding.
+	for _, tt := range []struct {
+		v    uint16
+		want float64
+	}{
+		{0x0100, 1.0 / 16 / (1 + 1.0/16)},
+		{0x1000, 0.5},
+	} {
+		m := image.NewRGBA64(image.Rect(0, 0, 1, 1))
+		m.SetRGBA64(0, 0, color.RGBA64{tt.v, tt.v, tt.v, 0xffff})
+		got := float64(ToneMap(m, 0xffff/float64(0x1000)).RGBAAt(0, 0).R)
+		if want := 255 * math.Pow(tt.want, 1/2.2); math.Abs(got-want) > 1 {
+			t.Errorf("0x%04x: got %v want %.1f", tt.v, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+)
+
+// ResizeFilter selects the resampling filter used by Resize.
+type ResizeFilter int
+
+const (
+	// BilinearFilter interpolates between the four source pixels nearest
+	// to each destination pixel center. It is fast, but aliases when
+	// shrinking by more than a factor of two.
+	BilinearFilter ResizeFilter = iota
+	// TriangleFilter is the tent filter with the same support and
+	// weighting as ImageMagick's -filter Triangle. When shrinking, the
+	// support is widened by the scale factor so every source pixel
+	// contributes, and the weights are normalized to sum to one. It is
+	// the same as resizing with BilinearResampler.
+	TriangleFilter
+)
+
+// scaler scales src to fill dst.
+type scaler func(dst draw.Image, src image.Image, cancel <-chan struct{}) error
+
+// scaler returns the function that scales with f.
+func (f ResizeFilter) scaler() (scaler, error) {
+	switch f {
+	case BilinearFilter:
+		return scale, nil
+	case TriangleFilter:
+		return resampleScaler(BilinearResampler), nil
+	}
+	return nil, errors.New("graphics: unknown resize filter")
+}
+
+// taps returns the number of source samples f weighs to scale an image
+// with bounds sb to db.
+func (f ResizeFilter) taps(db, sb image.Rectangle) int64 {
+	if f != TriangleFilter {
+		return 4 * int64(db.Dx()*db.Dy())
+	}
+	return resampleTapCount(BilinearResampler, db, sb)
+}
@@ -0,0 +1,66 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// The references were computed by hand from ImageMagick's resize.c with
+// -filter Triangle.
+var triangleTests = []struct {
+	src, want []uint8
+}{
+	// Shrinking widens the support to two source pixels.
+	{[]uint8{0, 60, 120, 240}, []uint8{43, 163}},
+	// Enlarging is bilinear, with the edge pixels replicated.
+	{[]uint8{0, 200}, []uint8{0, 50, 150, 200}},
+	{[]uint8{90}, []uint8{90, 90, 90}},
+}
+
+func TestResizeTriangle(t *testing.T) {
+	for _, tt := range triangleTests {
+		// Lay the samples out along both axes to check each pass.
+		for _, vertical := range []bool{false, true} {
+			r := func(n int) image.Rectangle {
+				if vertical {
+					return image.Rect(0, 0, 1, n)
+				}
+				return image.Rect(0, 0, n, 1)
+			}
+			at := func(i int) image.Point {
+				if vertical {
+					return image.Pt(0, i)
+				}
+				return image.Pt(i, 0)
+			}
+
+			src := image.NewRGBA(r(len(tt.src)))
+			for i, v := range tt.src {
+				p := at(i)
+				src.SetRGBA(p.X, p.Y, color.RGBA{v, v, v, 0xff})
+			}
+			dst := image.NewRGBA(r(len(tt.want)))
+			if err := Resize(dst, src, &ResizeOptions{Filter: TriangleFilter}); err != nil {
+				t.Fatal(err)
+			}
+			for i, v := range tt.want {
+				p := at(i)
+				if got := dst.RGBAAt(p.X, p.Y); got != (color.RGBA{v, v, v, 0xff}) {
+					t.Errorf("%v vertical %v: sample %d got %v want %d", tt.src, vertical, i, got, v)
+				}
+			}
+		}
+	}
+}
+
+func TestResizeUnknownFilter(t *testing.T) {
+	m := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	if err := Resize(m, m, &ResizeOptions{Filter: -1}); err == nil {
+		t.Error("expected error for unknown filter")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+)
+
+// TrimAlpha returns the tight bounding box of the pixels of src with
+// non-zero alpha, and a copy of that region translated to the origin.
+// If src is fully transparent, the rectangle is empty.
+func TrimAlpha(src image.Image) (*image.RGBA, image.Rectangle) {
+	b := src.Bounds()
+	r := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if _, _, _, a := src.At(x, y).RGBA(); a == 0 {
+				continue
+			}
+			if r.Empty() {
+				r = image.Rect(x, y, x+1, y+1)
+				continue
+			}
+			if x < r.Min.X {
+				r.Min.X = x
+			}
+			if x >= r.Max.X {
+				r.Max.X = x + 1
+			}
+			r.Max.Y = y + 1
+		}
+	}
+	return crop(src, r), r
+}
@@ -0,0 +1,50 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestTrimAlpha(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	// An L-shaped glyph, padded with transparency.
+	glyph := color.RGBA{0xff, 0, 0, 0xff}
+	for y := 4; y < 10; y++ {
+		src.SetRGBA(5, y, glyph)
+	}
+	for x := 5; x < 9; x++ {
+		src.SetRGBA(x, 9, glyph)
+	}
+	// A barely visible pixel still counts.
+	src.SetRGBA(11, 6, color.RGBA{0, 0, 0, 1})
+
+	m, r := TrimAlpha(src)
+	if want := image.Rect(5, 4, 12, 10); !r.Eq(want) {
+		t.Fatalf("got rect %v want %v", r, want)
+	}
+	if got, want := m.Bounds(), image.Rect(0, 0, 7, 6); !got.Eq(want) {
+		t.Fatalf("got bounds %v want %v", got, want)
+	}
+	if got := m.RGBAAt(0, 0); got != glyph {
+		t.Errorf("top-left: got %v want %v", got, glyph)
+	}
+	if got := m.RGBAAt(3, 5); got != glyph {
+		t.Errorf("bottom: got %v want %v", got, glyph)
+	}
+}
+
+func TestTrimAlphaTransparent(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	m, r := TrimAlpha(src)
+	if !r.Empty() {
+		t.Errorf("got rect %v want empty", r)
+	}
+	if !m.Bounds().Empty() {
+		t.Errorf("got bounds %v want empty", m.Bounds())
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/draw"
+)
+
+// UnsharpOptions are the unsharp masking parameters.
+// StdDev is the standard deviation of the Gaussian blur that defines the
+// detail to enhance. If zero, DefaultStdDev is used.
+// Amount scales the difference between the image and its blur that is
+// added back. If zero, it is 1.
+// LumaOnly sharpens only the luma of each pixel, leaving its chroma
+// untouched, which avoids amplifying color noise.
+type UnsharpOptions struct {
+	StdDev   float64
+	Amount   float64
+	LumaOnly bool
+}
+
+// UnsharpMask sharpens src by adding back the difference between it and a
+// blurred copy, and draws the result onto dst.
+func UnsharpMask(dst draw.Image, src image.Image, opt *UnsharpOptions) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	var o UnsharpOptions
+	if opt != nil {
+		o = *opt
+	}
+	if o.StdDev < 0 {
+		return ErrNegativeStdDev
+	}
+	if o.StdDev == 0 {
+		o.StdDev = DefaultStdDev
+	}
+	if o.Amount == 0 {
+		o.Amount = 1
+	}
+
+	b := dst.Bounds().Intersect(src.Bounds())
+	if b.Empty() {
+		return nil
+	}
+	s := crop(src, b)
+	blurred := image.NewRGBA(b)
+	if err := Blur(blurred, src, &BlurOptions{StdDev: o.StdDev}); err != nil {
+		return err
+	}
+
+	parallelRows(0, b.Dy(), func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			off := y * s.Stride
+			for x := 0; x < b.Dx(); x, off = x+1, off+4 {
+				sharpenPixel(s.Pix[off:off+4], blurred.Pix[off:off+4], o.Amount, o.LumaOnly)
+			}
+		}
+	})
+
+	draw.Draw(dst, b, s, image.Point{}, draw.Src)
+	return nil
+}
+
+// sharpenPixel adds k times the difference between the premultiplied
+// pixel p and its blur q back to p, in place. The colors are compared
+// unpremultiplied, so that transparent pixels blurred into q do not darken
+// the edges of an opaque shape. If lumaOnly is set, the difference in luma
+// is added to each channel instead, which keeps the chroma.
+func sharpenPixel(p, q []uint8, k float64, lumaOnly bool) {
+	if p[3] == 0 || q[3] == 0 {
+		return
+	}
+	a := float64(p[3])
+	// s scales the color of q to the alpha of p.
+	s := a / float64(q[3])
+	if lumaOnly {
+		// Adding the same amount to each channel changes the luma by
+		// that amount and keeps the chroma.
+		dl := k * (luma(p[0], p[1], p[2]) - s*luma(q[0], q[1], q[2]))
+		for c := 0; c < 3; c++ {
+			p[c] = clampAlpha(float64(p[c])+dl, a)
+		}
+		return
+	}
+	for c := 0; c < 3; c++ {
+		v := float64(p[c])
+		p[c] = clampAlpha(v+k*(v-s*float64(q[c])), a)
+	}
+}
+
+// clampAlpha rounds f to a premultiplied channel value in [0, a].
+func clampAlpha(f, a float64) uint8 {
+	if f > a {
+		f = a
+	}
+	return clamp8(f)
+}
@@ -0,0 +1,79 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// newColorStep returns a w by h opaque image with a vertical edge between
+// two colors of equal chroma and different luma.
+func newColorStep(w, h int) *image.RGBA {
+	m := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0x3c, 0x50, 0x64, 0xff}
+			if x >= w/2 {
+				c = color.RGBA{0x96, 0xaa, 0xbe, 0xff}
+			}
+			m.SetRGBA(x, y, c)
+		}
+	}
+	return m
+}
+
+func TestUnsharpMask(t *testing.T) {
+	src := newColorStep(16, 4)
+	for _, lumaOnly := range []bool{false, true} {
+		dst := image.NewRGBA(src.Bounds())
+		opt := &UnsharpOptions{StdDev: 1, Amount: 1, LumaOnly: lumaOnly}
+		if err := UnsharpMask(dst, src, opt); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			// The edge overshoots on both sides.
+			l, r := dst.RGBAAt(7, y), dst.RGBAAt(8, y)
+			if l.G >= 0x50 || r.G <= 0xaa {
+				t.Errorf("luma only %v, row %d: edge not sharpened: %v %v", lumaOnly, y, l, r)
+			}
+			// Flat regions are unchanged.
+			if c := dst.RGBAAt(0, y); c != src.RGBAAt(0, y) {
+				t.Errorf("luma only %v, row %d: flat region changed to %v", lumaOnly, y, c)
+			}
+		}
+	}
+}
+
+func TestUnsharpMaskLumaOnly(t *testing.T) {
+	// Red and cyan halves, which differ in chroma as well as luma.
+	src := image.NewRGBA(image.Rect(0, 0, 16, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.RGBA{0x90, 0x50, 0x50, 0xff}
+			if x >= 8 {
+				c = color.RGBA{0x70, 0xb0, 0xb0, 0xff}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	dst := image.NewRGBA(src.Bounds())
+	if err := UnsharpMask(dst, src, &UnsharpOptions{StdDev: 1, Amount: 1, LumaOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for x := 0; x < 16; x++ {
+		s, d := src.RGBAAt(x, 0), dst.RGBAAt(x, 0)
+		sy, scb, scr := color.RGBToYCbCr(s.R, s.G, s.B)
+		dy, dcb, dcr := color.RGBToYCbCr(d.R, d.G, d.B)
+		if absDiff(uint32(scb), uint32(dcb)) > 1 || absDiff(uint32(scr), uint32(dcr)) > 1 {
+			t.Errorf("x=%d: chroma changed from (%d, %d) to (%d, %d)", x, scb, scr, dcb, dcr)
+		}
+		if x == 7 && dy >= sy || x == 8 && dy <= sy {
+			t.Errorf("x=%d: luma %d not sharpened from %d", x, dy, sy)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// Vibrance adjusts the saturation of src and draws the result onto dst.
+// Unlike a uniform saturation change, it scales the boost by how
+// unsaturated each pixel is, so muted colors gain the most and vivid ones
+// little. Skin tones get half the boost, and no channel is pushed out of
+// range, so colors are not clipped. An amount of 1 doubles the saturation
+// of a gray-ish pixel, 0 leaves src unchanged, and a negative amount
+// desaturates. Alpha is unchanged.
+func Vibrance(dst draw.Image, src image.Image, amount float64) error {
+	return mapPixels(dst, src, func(_, _ int, p color.RGBA) color.RGBA {
+		a := float64(p.A)
+		if a == 0 {
+			return color.RGBA{}
+		}
+		// Work on the non-premultiplied color.
+		c := [3]float64{
+			float64(p.R) * 0xff / a,
+			float64(p.G) * 0xff / a,
+			float64(p.B) * 0xff / a,
+		}
+		f := vibranceFactor(c, amount)
+		l := 0.299*c[0] + 0.587*c[1] + 0.114*c[2]
+		var q [3]uint8
+		for i := range c {
+			q[i] = clampAlpha((l+(c[i]-l)*f)*a/0xff, a)
+		}
+		return color.RGBA{q[0], q[1], q[2], p.A}
+	})
+}
+
+// vibranceFactor returns the factor by which Vibrance scales the distance
+// of each channel of the non-premultiplied color c from its luma.
+func vibranceFactor(c [3]float64, amount float64) float64 {
+	hi := math.Max(c[0], math.Max(c[1], c[2]))
+	lo := math.Min(c[0], math.Min(c[1], c[2]))
+	gain := amount * (1 - (hi-lo)/0xff)
+	// Skin tones are warm and moderately saturated: red over green over
+	// blue.
+	if c[0] > c[1] && c[1] > c[2] && (hi-lo)/hi > 0.1 && (hi-lo)/hi < 0.6 {
+		gain /= 2
+	}
+	f := math.Max(1+gain, 0)
+
+	// Limit the factor so no channel leaves [0, 0xff].
+	l := 0.299*c[0] + 0.587*c[1] + 0.114*c[2]
+	for _, v := range c {
+		switch {
+		case v > l:
+			f = math.Min(f, math.Max((0xff-l)/(v-l), 1))
+		case v < l:
+			f = math.Min(f, math.Max(l/(l-v), 1))
+		}
+	}
+	return f
+}
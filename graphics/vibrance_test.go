@@ -0,0 +1,58 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// chroma returns the spread between the largest and smallest color
+// channels of c.
+func chroma(c color.RGBA) int {
+	hi, lo := c.R, c.R
+	for _, v := range []uint8{c.G, c.B} {
+		if v > hi {
+			hi = v
+		}
+		if v < lo {
+			lo = v
+		}
+	}
+	return int(hi) - int(lo)
+}
+
+func TestVibrance(t *testing.T) {
+	muted := color.RGBA{0x78, 0x82, 0x96, 0xff}
+	vivid := color.RGBA{0xd0, 0x20, 0x40, 0xff}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.SetRGBA(0, 0, muted)
+	src.SetRGBA(1, 0, vivid)
+	dst := image.NewRGBA(src.Bounds())
+	if err := Vibrance(dst, src, 0.8); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare the relative growth of the saturation.
+	gm := float64(chroma(dst.RGBAAt(0, 0)))/float64(chroma(muted)) - 1
+	gv := float64(chroma(dst.RGBAAt(1, 0)))/float64(chroma(vivid)) - 1
+	if gm <= gv || gm <= 0 {
+		t.Errorf("muted chroma grew by %.0f%%, vivid by %.0f%%; want muted to grow more", 100*gm, 100*gv)
+	}
+	// The vivid color is not clipped: its hue is kept.
+	if c := dst.RGBAAt(1, 0); c.R == 0xff && c.G == 0 {
+		t.Errorf("vivid color clipped to %v", c)
+	}
+
+	// A zero amount is the identity.
+	if err := Vibrance(dst, src, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// WarpOptions are the optional parameters of WarpWithOptions.
+// Edge is how samples outside src are filled. The zero value, PadZero,
+// makes them transparent.
+type WarpOptions struct {
+	Edge PadMode
+}
+
+// Warp draws src onto dst displaced pixel by pixel, for glass, ripple and
+// liquify effects. The red and green channels of displacement at each
+// pixel of dst give the horizontal and vertical offsets of the point of
+// src that is sampled there, with bilinear interpolation: a value v gives
+// an offset of scale*(v-128)/128 pixels, so 128 leaves the pixel in place.
+// Pixels of dst outside displacement are not displaced. Samples outside src
+// are transparent.
+func Warp(dst draw.Image, src image.Image, displacement *image.RGBA, scale float64) error {
+	return WarpWithOptions(dst, src, displacement, scale, nil)
+}
+
+// WarpWithOptions is like Warp but takes optional parameters. A nil opt is
+// equivalent to Warp.
+func WarpWithOptions(dst draw.Image, src image.Image, displacement *image.RGBA, scale float64, opt *WarpOptions) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	var o WarpOptions
+	if opt != nil {
+		o = *opt
+	}
+	k := scale / 128
+	return warp(dst, src, o.Edge, func(x, y int) (dx, dy float64) {
+		if displacement == nil || !image.Pt(x, y).In(displacement.Rect) {
+			return 0, 0
+		}
+		p := displacement.Pix[displacement.PixOffset(x, y):]
+		return k * (float64(p[0]) - 128), k * (float64(p[1]) - 128)
+	})
+}
+
+// warp draws onto dst, at each pixel (x, y), src sampled with bilinear
+// interpolation at the pixel center offset by disp(x, y), filling samples
+// outside src according to edge. disp is called concurrently.
+func warp(dst draw.Image, src image.Image, edge PadMode, disp func(x, y int) (dx, dy float64)) error {
+	b := dst.Bounds()
+	if b.Empty() {
+		return nil
+	}
+	sb := src.Bounds()
+	s := crop(src, sb)
+	d := image.NewRGBA(b)
+	if !sb.Empty() {
+		parallelRows(b.Min.Y, b.Max.Y, func(y0, y1 int) {
+			for y := y0; y < y1; y++ {
+				off := (y - b.Min.Y) * d.Stride
+				for x := b.Min.X; x < b.Max.X; x, off = x+1, off+4 {
+					dx, dy := disp(x, y)
+					// The sample, relative to src, with pixel centers at
+					// whole co-ordinates.
+					fx := float64(x-sb.Min.X) + dx
+					fy := float64(y-sb.Min.Y) + dy
+					c := sampleBilinear(s, fx, fy, edge)
+					a := clamp8(c[3])
+					d.Pix[off+0] = clampAlpha(c[0], float64(a))
+					d.Pix[off+1] = clampAlpha(c[1], float64(a))
+					d.Pix[off+2] = clampAlpha(c[2], float64(a))
+					d.Pix[off+3] = a
+				}
+			}
+		})
+	}
+	draw.Draw(dst, b, d, b.Min, draw.Src)
+	return nil
+}
+
+// sampleBilinear returns the premultiplied color of s, whose bounds start
+// at the origin, interpolated at (x, y), where pixel centers are at whole
+// co-ordinates. Pixels outside s are filled according to edge.
+func sampleBilinear(s *image.RGBA, x, y float64, edge PadMode) (c [4]float64) {
+	w, h := s.Rect.Dx(), s.Rect.Dy()
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	for j := 0; j < 2; j++ {
+		wy := 1 - ty
+		if j == 1 {
+			wy = ty
+		}
+		if wy == 0 {
+			continue
+		}
+		sy, ok := padIndex(iy+j, h, edge)
+		if !ok {
+			continue
+		}
+		for i := 0; i < 2; i++ {
+			wx := 1 - tx
+			if i == 1 {
+				wx = tx
+			}
+			if wx == 0 {
+				continue
+			}
+			sx, ok := padIndex(ix+i, w, edge)
+			if !ok {
+				continue
+			}
+			p := s.Pix[sy*s.Stride+sx*4:]
+			for ch := range c {
+				c[ch] += float64(p[ch]) * wx * wy
+			}
+		}
+	}
+	return c
+}
+
+// Ripple draws src onto dst with each row shifted horizontally along a sine
+// wave, like a reflection in rippling water, or each column shifted
+// vertically if vertical is set. The row or column i, counting from the
+// top-left of src, is shifted by amplitude*sin(2*pi*i/wavelength) pixels.
+// Samples beyond the edges of src repeat the edge pixels. wavelength must
+// be positive.
+func Ripple(dst draw.Image, src image.Image, amplitude, wavelength float64, vertical bool) error {
+	if dst == nil {
+		return ErrNilDst
+	}
+	if src == nil {
+		return ErrNilSrc
+	}
+	if !(wavelength > 0) {
+		return errors.New("graphics: wavelength must be positive")
+	}
+	sb := src.Bounds()
+	k := 2 * math.Pi / wavelength
+	return warp(dst, src, PadEdge, func(x, y int) (dx, dy float64) {
+		// Shifting a row by d samples it d pixels back.
+		if vertical {
+			return 0, -amplitude * math.Sin(k*float64(x-sb.Min.X))
+		}
+		return -amplitude * math.Sin(k*float64(y-sb.Min.Y)), 0
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestWarp(t *testing.T) {
+	src := NewNoise(20, 16, 1)
+	// An offset of (4, -2) pixels: each pixel of dst is sampled from
+	// src 4 to the right and 2 up, so the image shifts left and down.
+	disp := newUniformRGBA(src.Bounds(), color.RGBA{128 + 64, 128 - 32, 0, 0xff})
+	dst := image.NewRGBA(src.Bounds())
+	if err := Warp(dst, src, disp, 8); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewRGBA(src.Bounds())
+	if err := I.Translate(-4, 2).Transform(want, src, interp.Bilinear); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, want, 0); err != nil {
+		t.Error(err)
+	}
+	// The strip uncovered on the right is transparent.
+	if c := dst.RGBAAt(19, 8); c.A != 0 {
+		t.Errorf("got %v uncovered, want transparent", c)
+	}
+
+	// With the edge repeated, it is filled from the last column.
+	if err := WarpWithOptions(dst, src, disp, 8, &WarpOptions{Edge: PadEdge}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dst.RGBAAt(19, 8), src.RGBAAt(19, 6); got != want {
+		t.Errorf("edge: got %v want %v", got, want)
+	}
+
+	// A neutral map, or none, leaves src as it is.
+	neutral := newUniformRGBA(src.Bounds(), color.RGBA{128, 128, 0, 0xff})
+	for _, m := range []*image.RGBA{neutral, nil} {
+		if err := Warp(dst, src, m, 8); err != nil {
+			t.Fatal(err)
+		}
+		if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRipple(t *testing.T) {
+	src := NewNoise(30, 12, 1)
+	// Translated to check that the phase counts from src's top-left.
+	src.Rect = src.Rect.Add(image.Pt(5, -3))
+
+	// Amplitude 0 is the identity.
+	dst := image.NewRGBA(src.Bounds())
+	if err := Ripple(dst, src, 0, 8, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := graphicstest.ImageWithinTolerance(dst, src, 0); err != nil {
+		t.Errorf("amplitude 0: %v", err)
+	}
+
+	// With a wavelength of 8, rows 2 and 6 are a quarter and three
+	// quarters of the way along the wave, so shifted fully right and left.
+	if err := Ripple(dst, src, 3, 8, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ row, shift int }{{0, 0}, {2, 3}, {6, -3}, {8, 0}} {
+		y := src.Rect.Min.Y + tt.row
+		for x := src.Rect.Min.X + 3; x < src.Rect.Max.X-3; x++ {
+			if got, want := dst.RGBAAt(x, y), src.RGBAAt(x-tt.shift, y); got != want {
+				t.Fatalf("row %d: (%d, %d) got %v want %v", tt.row, x, y, got, want)
+			}
+		}
+	}
+	// The edges are clamped, not transparent.
+	if c := dst.RGBAAt(src.Rect.Min.X, src.Rect.Min.Y+2); c != src.RGBAAt(src.Rect.Min.X, src.Rect.Min.Y+2) {
+		t.Errorf("got %v at the clamped edge, want the edge pixel", c)
+	}
+
+	// Vertically, the columns move instead.
+	if err := Ripple(dst, src, 2, 8, true); err != nil {
+		t.Fatal(err)
+	}
+	x := src.Rect.Min.X + 2
+	for y := src.Rect.Min.Y + 2; y < src.Rect.Max.Y-2; y++ {
+		if got, want := dst.RGBAAt(x, y), src.RGBAAt(x, y-2); got != want {
+			t.Fatalf("vertical: (%d, %d) got %v want %v", x, y, got, want)
+		}
+	}
+
+	if err := Ripple(dst, src, 1, 0, false); err == nil {
+		t.Error("zero wavelength: got no error")
+	}
+}
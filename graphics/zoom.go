@@ -0,0 +1,43 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/interp"
+	"image"
+)
+
+// ZoomFrames returns n frames of a zoom and pan across src, as in the Ken
+// Burns effect. Each frame is the size of from and shows the region of src
+// interpolated linearly from the rectangle from, in the first frame, to the
+// rectangle to, in the last, scaled to fit the frame with bilinear
+// interpolation. The rectangles must not be empty. ZoomFrames returns nil
+// if src is nil, n is less than 1 or a frame cannot be drawn.
+func ZoomFrames(src image.Image, from, to image.Rectangle, n int) []*image.RGBA {
+	if src == nil || n < 1 || from.Empty() || to.Empty() {
+		return nil
+	}
+	fw, fh := float64(from.Dx()), float64(from.Dy())
+	frames := make([]*image.RGBA, n)
+	for i := range frames {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		x0 := lerp(float64(from.Min.X), float64(to.Min.X), t)
+		y0 := lerp(float64(from.Min.Y), float64(to.Min.Y), t)
+		x1 := lerp(float64(from.Max.X), float64(to.Max.X), t)
+		y1 := lerp(float64(from.Max.Y), float64(to.Max.Y), t)
+
+		dst := image.NewRGBA(image.Rect(0, 0, from.Dx(), from.Dy()))
+		a := I.Translate(-x0, -y0).Scale(fw/(x1-x0), fh/(y1-y0))
+		// The rectangles are not empty, so a is never degenerate.
+		if err := a.Transform(dst, src, interp.Bilinear); err != nil {
+			return nil
+		}
+		frames[i] = dst
+	}
+	return frames
+}
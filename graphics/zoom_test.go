@@ -0,0 +1,63 @@
+// Copyright 2011 The Graphics-Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package graphics
+
+import (
+	"github.com/image-server/graphics-go/graphics/graphicstest"
+	"image"
+	"testing"
+
+	_ "image/png"
+)
+
+func TestZoomFrames(t *testing.T) {
+	src, err := graphicstest.LoadImage("../testdata/gopher.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := image.Rect(0, 0, 200, 300)
+	to := image.Rect(150, 250, 250, 400)
+
+	frames := ZoomFrames(src, from, to, 5)
+	if len(frames) != 5 {
+		t.Fatalf("got %d frames, want 5", len(frames))
+	}
+	for i, f := range frames {
+		if got, want := f.Bounds(), image.Rect(0, 0, 200, 300); got != want {
+			t.Errorf("frame %d: bounds %v want %v", i, got, want)
+		}
+	}
+
+	// The first frame is the from crop exactly.
+	if err := graphicstest.ImageWithinTolerance(frames[0], crop(src, from), 0); err != nil {
+		t.Errorf("first frame: %v", err)
+	}
+	// The last frame is the to crop, scaled to the frame size. Scaling
+	// the crop alone clamps samples at its edges, so compare the interior.
+	last := image.NewRGBA(frames[4].Bounds())
+	if err := Scale(last, toRGBA(src).SubImage(to)); err != nil {
+		t.Fatal(err)
+	}
+	inner := last.Bounds().Inset(2)
+	if err := graphicstest.ImageWithinTolerance(crop(frames[4], inner), crop(last, inner), 0x101); err != nil {
+		t.Errorf("last frame: %v", err)
+	}
+
+	// A pan between equal sizes ends on the to crop exactly.
+	pan := ZoomFrames(src, from, from.Add(image.Pt(100, 200)), 3)
+	if err := graphicstest.ImageWithinTolerance(pan[2], crop(src, from.Add(image.Pt(100, 200))), 0); err != nil {
+		t.Errorf("pan: %v", err)
+	}
+}
+
+func TestZoomFramesNil(t *testing.T) {
+	r := image.Rect(0, 0, 10, 10)
+	if got := ZoomFrames(nil, r, r, 4); got != nil {
+		t.Errorf("nil src: got %d frames want none", len(got))
+	}
+	if got := ZoomFrames(NewNoise(10, 10, 1), r, r, 0); got != nil {
+		t.Errorf("n = 0: got %d frames want none", len(got))
+	}
+}